@@ -3,10 +3,16 @@ package main
 import (
 	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
+	"maps"
 	"os"
 	"path"
+	"path/filepath"
 	"regexp"
 	"slices"
 	"strconv"
@@ -35,6 +41,43 @@ func parseNixContainerLabels(c *NixContainer, sopsConfig *SopsConfig) error {
 			} else {
 				return fmt.Errorf("compose2nix.settings.autoStart must be: true or false")
 			}
+		case label == "compose2nix.settings.waitForHealthy":
+			if v == "true" {
+				c.WaitForHealthy = true
+			} else if v == "false" {
+				c.WaitForHealthy = false
+			} else {
+				return fmt.Errorf("compose2nix.settings.waitForHealthy must be: true or false")
+			}
+		case label == "compose2nix.settings.healthOnFailure":
+			switch v {
+			case "none", "kill", "restart", "stop":
+				c.HealthOnFailure = v
+			default:
+				return fmt.Errorf("compose2nix.settings.healthOnFailure must be one of: none, kill, restart, stop")
+			}
+		case label == "compose2nix.settings.schedule":
+			if v = strings.TrimSpace(v); v == "" {
+				return fmt.Errorf("compose2nix.settings.schedule must not be empty")
+			}
+			containerSchedule(c).OnCalendar = v
+		case label == "compose2nix.settings.schedule.persistent":
+			if v == "true" {
+				containerSchedule(c).Persistent = true
+			} else if v == "false" {
+				containerSchedule(c).Persistent = false
+			} else {
+				return fmt.Errorf("compose2nix.settings.schedule.persistent must be: true or false")
+			}
+		case label == "compose2nix.settings.schedule.randomizedDelaySec":
+			containerSchedule(c).RandomizedDelaySec = strings.TrimSpace(v)
+		case label == "compose2nix.settings.nixImage":
+			if v = strings.TrimSpace(v); v == "" {
+				return fmt.Errorf("compose2nix.settings.nixImage must not be empty")
+			}
+			c.NixImage = v
+		case label == "compose2nix.settings.buildRef":
+			c.BuildRef = strings.TrimSpace(v)
 		case label == "compose2nix.settings.sops.secrets":
 			if sopsConfig == nil {
 				return fmt.Errorf("compose2nix.settings.sops.secrets defined, but not sops config specified")
@@ -56,9 +99,21 @@ func parseNixContainerLabels(c *NixContainer, sopsConfig *SopsConfig) error {
 			return fmt.Errorf("invalid compose2nix container label: %q", label)
 		}
 	}
+	if c.Schedule != nil && c.Schedule.OnCalendar == "" {
+		return fmt.Errorf("compose2nix.settings.schedule must be set when using other compose2nix.settings.schedule.* labels")
+	}
 	return nil
 }
 
+// containerSchedule returns the container's schedule, creating it if needed.
+func containerSchedule(c *NixContainer) *NixContainerSchedule {
+	if c.Schedule == nil {
+		// Run missed jobs on boot by default.
+		c.Schedule = &NixContainerSchedule{Persistent: true}
+	}
+	return c.Schedule
+}
+
 func composeEnvironmentToMap(env types.MappingWithEquals) map[string]string {
 	m := map[string]string{}
 	for k, v := range env {
@@ -137,8 +192,20 @@ type Generator struct {
 	EnableOption            bool
 	SopsConfig              *SopsConfig
 	WarningsAsErrors        bool
+	WaitForHealthy          bool
+	WaitForHealthyTimeout   time.Duration
+	PullUnits               bool
+	PullTimeout             time.Duration
+	PullRetries             int
+	RegistryAuth            RegistryAuth
+	ImageRewriteRules       []ImageRewriteRule
+	BuildTriggers           BuildTriggerMode
+	OutputDir               string // Used to reference build contexts as Nix paths. Defaults to the root path.
+	BuildServiceConfig      ServiceConfig
 
 	serviceToContainerName map[string]string
+	serviceToLinkAliases   map[string][]string
+	serviceKeys            map[string]map[string]bool // Keys explicitly set on each service.
 	rootPath               string
 }
 
@@ -193,11 +260,34 @@ func (g *Generator) Run(ctx context.Context) (*NixContainerConfig, error) {
 			o.SetProjectName(g.Project.Name, true)
 		})
 	}
-	composeProject, err := loader.LoadWithContext(ctx, types.ConfigDetails{
+	configDetails := types.ConfigDetails{
 		ConfigFiles: types.ToConfigFiles(g.Inputs),
 		Environment: types.NewMapping(env),
 		WorkingDir:  rootPath,
-	}, opts...)
+	}
+	// Load the raw model first so that we can tell apart keys that were set to
+	// their zero value from keys that were not set at all.
+	model, err := loader.LoadModelWithContext(ctx, configDetails, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Compose project: %w", err)
+	}
+	g.serviceKeys = map[string]map[string]bool{}
+	if services, ok := model["services"].(map[string]any); ok {
+		for name, service := range services {
+			keys := map[string]bool{}
+			if m, ok := service.(map[string]any); ok {
+				for k := range m {
+					keys[k] = true
+				}
+			}
+			g.serviceKeys[name] = keys
+		}
+	}
+	loaderOpts := loader.ToOptions(&configDetails, opts)
+	if name, ok := model["name"].(string); ok {
+		loaderOpts.SetProjectName(name, true)
+	}
+	composeProject, err := loader.ModelToProject(model, loaderOpts, configDetails)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse Compose project: %w", err)
 	}
@@ -208,6 +298,10 @@ func (g *Generator) Run(ctx context.Context) (*NixContainerConfig, error) {
 		g.Project = NewProject(composeProject.Name)
 	}
 
+	if err := g.checkUnsupportedProjectKeys(composeProject); err != nil {
+		return nil, err
+	}
+
 	// Construct a map of service to container name.
 	g.serviceToContainerName = map[string]string{}
 	for _, service := range composeProject.Services {
@@ -220,15 +314,34 @@ func (g *Generator) Run(ctx context.Context) (*NixContainerConfig, error) {
 		g.serviceToContainerName[service.Name] = name
 	}
 
-	networks, networkMap := g.buildNixNetworks(composeProject)
-	volumes, volumeMap := g.buildNixVolumes(composeProject)
+	// Legacy links are converted into network aliases on the linked service.
+	// https://docs.docker.com/reference/compose-file/services/#links
+	g.serviceToLinkAliases = map[string][]string{}
+	for _, service := range composeProject.Services {
+		for _, link := range service.Links {
+			target, alias := parseLink(link)
+			if alias == target || slices.Contains(g.serviceToLinkAliases[target], alias) {
+				continue
+			}
+			g.serviceToLinkAliases[target] = append(g.serviceToLinkAliases[target], alias)
+		}
+	}
+
+	networks, networkMap, err := g.buildNixNetworks(composeProject)
+	if err != nil {
+		return nil, err
+	}
+	volumes, volumeMap, err := g.buildNixVolumes(composeProject)
+	if err != nil {
+		return nil, err
+	}
 	containers, builds, err := g.buildNixContainers(composeProject, networkMap, volumeMap)
 	if err != nil {
 		return nil, err
 	}
 
 	// Post-process any Compose settings that require the full state.
-	networks, volumes = g.postProcess(containers, networks, volumes)
+	networks, volumes = g.postProcess(containers, builds, networks, volumes)
 
 	var version string
 	if g.WriteHeader {
@@ -262,10 +375,13 @@ func (g *Generator) Run(ctx context.Context) (*NixContainerConfig, error) {
 	}, nil
 }
 
-func (g *Generator) postProcess(containers []*NixContainer, networks []*NixNetwork, volumes []*NixVolume) ([]*NixNetwork, []*NixVolume) {
-	// Drop any networks that are unused or external.
+func (g *Generator) postProcess(containers []*NixContainer, builds []*NixBuild, networks []*NixNetwork, volumes []*NixVolume) ([]*NixNetwork, []*NixVolume) {
+	// Drop any networks that are unused or external. A network is also used if
+	// a build runs on it, since the build unit depends on the network unit.
 	networks = slices.DeleteFunc(networks, func(n *NixNetwork) bool {
-		used := false
+		used := slices.ContainsFunc(builds, func(b *NixBuild) bool {
+			return b.Network == n.Name
+		})
 		for _, c := range containers {
 			if slices.Contains(c.Networks, n.Name) {
 				used = true
@@ -304,6 +420,14 @@ func healthCheckCommandToString(cmd []string) (string, error) {
 	panic("unreachable")
 }
 
+func hasHealthCheck(service types.ServiceConfig) bool {
+	healthCheck := service.HealthCheck
+	if healthCheck == nil || healthCheck.Disable || len(healthCheck.Test) == 0 {
+		return false
+	}
+	return healthCheck.Test[0] != "NONE"
+}
+
 // Health check.
 // https://docs.docker.com/compose/compose-file/05-services/#healthcheck
 func parseHealthCheck(c *NixContainer, service types.ServiceConfig, runtime ContainerRuntime) error {
@@ -345,6 +469,48 @@ func parseHealthCheck(c *NixContainer, service types.ServiceConfig, runtime Cont
 	return nil
 }
 
+// Lifecycle hooks.
+// https://docs.docker.com/reference/compose-file/services/#post_start
+// https://docs.docker.com/reference/compose-file/services/#pre_stop
+func parseServiceHooks(c *NixContainer, service types.ServiceConfig, runtime ContainerRuntime) {
+	if len(service.PostStart) > 0 && runtime == ContainerRuntimeDocker {
+		// Podman notifies systemd once the container is running. Docker does
+		// not, so we need to wait for the container before exec'ing into it.
+		c.PostStart = append(c.PostStart, fmt.Sprintf(
+			`until %[1]s container inspect --format '{{.State.Running}}' %[2]s 2>/dev/null | grep -q true; do if [ "$SECONDS" -ge %[3]d ]; then echo "timed out waiting for %[2]s to start" >&2; exit 1; fi; sleep 1; done`,
+			runtime, shellQuote(c.Name), int(postStartWaitTimeout.Seconds())))
+	}
+	for _, hook := range service.PostStart {
+		c.PostStart = append(c.PostStart, hookToExecCommand(hook, c.Name, runtime))
+	}
+	for _, hook := range service.PreStop {
+		// A failed hook cannot abort a systemd stop, so make sure that the
+		// container is always stopped.
+		c.PreStop = append(c.PreStop, hookToExecCommand(hook, c.Name, runtime)+" || true")
+	}
+}
+
+func hookToExecCommand(hook types.ServiceHook, containerName string, runtime ContainerRuntime) string {
+	args := []string{runtime.String(), "exec"}
+	if hook.User != "" {
+		args = append(args, "--user="+shellQuote(hook.User))
+	}
+	if hook.Privileged {
+		args = append(args, "--privileged")
+	}
+	if hook.WorkingDir != "" {
+		args = append(args, "--workdir="+shellQuote(hook.WorkingDir))
+	}
+	for _, env := range mapToKeyValArray(composeEnvironmentToMap(hook.Environment)) {
+		args = append(args, "--env="+shellQuote(env))
+	}
+	args = append(args, shellQuote(containerName))
+	for _, arg := range hook.Command {
+		args = append(args, shellQuote(arg))
+	}
+	return strings.Join(args, " ")
+}
+
 func (g *Generator) handleVolumesForService(service types.ServiceConfig, volumeMap map[string]*NixVolume, c *NixContainer) error {
 	for _, v := range service.Volumes {
 		// Handle tmpfs volumes first.
@@ -458,6 +624,34 @@ func (g *Generator) handleVolumesForService(service types.ServiceConfig, volumeM
 		}
 	}
 
+	// https://docs.docker.com/reference/compose-file/services/#volumes_from
+	// https://docs.podman.io/en/latest/markdown/podman-run.1.html#volumes-from-container-options
+	for _, from := range service.VolumesFrom {
+		parts := strings.Split(strings.TrimSpace(from), ":")
+		var targetContainerName string
+		if parts[0] == "container" && len(parts) > 1 {
+			targetContainerName = parts[1]
+			parts = parts[2:]
+		} else {
+			name, ok := g.serviceToContainerName[parts[0]]
+			if !ok {
+				return fmt.Errorf("volumes_from for service %q refers to a non-existent service %q", service.Name, parts[0])
+			}
+			targetContainerName = name
+			parts = parts[1:]
+		}
+
+		volumesFrom := targetContainerName
+		if len(parts) > 0 {
+			if len(parts) > 1 || (parts[0] != "ro" && parts[0] != "rw") {
+				return fmt.Errorf("service %q: invalid volumes_from %q - access mode must be one of: ro, rw", service.Name, from)
+			}
+			volumesFrom += ":" + parts[0]
+		}
+		c.ExtraOptions = append(c.ExtraOptions, "--volumes-from="+volumesFrom)
+		g.dependOnContainer(c, targetContainerName)
+	}
+
 	// Handle tmpfs short syntax.
 	// https://docs.docker.com/reference/compose-file/services/#tmpfs
 	for _, tmpfs := range service.Tmpfs {
@@ -476,26 +670,489 @@ func (g *Generator) checkOrWarn(format string, args ...any) error {
 	return nil
 }
 
+// checkOrWarnAll calls checkOrWarn for each warning, prefixed by the resource
+// that it applies to.
+func (g *Generator) checkOrWarnAll(resource string, warnings []string) error {
+	for _, w := range warnings {
+		if err := g.checkOrWarn("%s: %s", resource, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseLink splits a "[name]:[alias]" link into its name and alias. If no alias
+// is set, the name is used as the alias.
+func parseLink(link string) (name, alias string) {
+	name, alias, ok := strings.Cut(strings.TrimSpace(link), ":")
+	if !ok {
+		alias = name
+	}
+	return name, alias
+}
+
+// https://docs.docker.com/reference/compose-file/services/#external_links
+func (g *Generator) handleExternalLinksForService(service types.ServiceConfig, c *NixContainer) error {
+	for _, link := range service.ExternalLinks {
+		targetContainerName, alias := parseLink(link)
+		switch g.Runtime {
+		case ContainerRuntimeDocker:
+			// Docker still supports links on user-defined networks, which
+			// makes the alias resolvable from this container only.
+			// https://docs.docker.com/reference/cli/docker/container/run/#link
+			c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("--link=%s:%s", targetContainerName, alias))
+		case ContainerRuntimePodman:
+			// Podman does not support links. The container name is resolvable
+			// through DNS on shared networks, but a distinct alias is not.
+			if alias != targetContainerName {
+				if err := g.checkOrWarn("service %q: external_links alias %q for container %q is not supported for %s runtime and will be ignored", service.Name, alias, targetContainerName, g.Runtime); err != nil {
+					return err
+				}
+			}
+		}
+
+		g.dependOnContainer(c, targetContainerName)
+	}
+	return nil
+}
+
+// translatePodmanBuildOptions converts BuildKit-specific build options into
+// their Podman equivalents, or drops them with a warning if there is none.
+// https://docs.podman.io/en/latest/markdown/podman-build.1.html#cache-from
+func (g *Generator) translatePodmanBuildOptions(service types.ServiceConfig, b *NixBuild) error {
+	var err error
+	if b.CacheFrom, err = g.translatePodmanCache(service, "cache_from", b.CacheFrom); err != nil {
+		return err
+	}
+	if b.CacheTo, err = g.translatePodmanCache(service, "cache_to", b.CacheTo); err != nil {
+		return err
+	}
+	return nil
+}
+
+// translatePodmanCache converts BuildKit cache specs (e.g.,
+// "type=registry,ref=[repo]") into the plain repository names that Podman
+// expects. Only registry caches are supported by Podman.
+func (g *Generator) translatePodmanCache(service types.ServiceConfig, key string, caches []string) ([]string, error) {
+	var repos []string
+	for _, cache := range caches {
+		if !strings.Contains(cache, "=") {
+			// Short syntax: an image reference.
+			repos = append(repos, cache)
+			continue
+		}
+		attrs := map[string]string{}
+		for _, attr := range strings.Split(cache, ",") {
+			k, v, _ := strings.Cut(attr, "=")
+			attrs[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		if attrs["type"] != "registry" || attrs["ref"] == "" {
+			if err := g.checkOrWarn("service %q: build %s %q is not supported for %s runtime and will be ignored", service.Name, key, cache, g.Runtime); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		repos = append(repos, attrs["ref"])
+	}
+	return repos, nil
+}
+
+// dependOnContainer adds a dependency on a container that may or may not be
+// part of this Compose project.
+//
+// Containers in this project are added to DependsOn. Otherwise, this container
+// is ordered after the other container's service in case it is managed by
+// systemd. systemd ignores ordering on units that do not exist.
+func (g *Generator) dependOnContainer(c *NixContainer, targetContainerName string) {
+	if !slices.Contains(slices.Collect(maps.Values(g.serviceToContainerName)), targetContainerName) {
+		c.SystemdConfig.Unit.After = append(c.SystemdConfig.Unit.After, g.containerNameToService(targetContainerName))
+		return
+	}
+	if !slices.Contains(c.DependsOn, targetContainerName) {
+		c.DependsOn = append(c.DependsOn, targetContainerName)
+	}
+}
+
+// resolveNamespaceMode converts a Compose "service:[name]" namespace mode to a
+// "container:[name]" mode. Any other mode is returned as-is.
+func (g *Generator) resolveNamespaceMode(service types.ServiceConfig, key, mode string) (string, error) {
+	mode = strings.TrimSpace(mode)
+	if targetService, ok := strings.CutPrefix(mode, "service:"); ok {
+		targetContainerName, ok := g.serviceToContainerName[targetService]
+		if !ok {
+			return "", fmt.Errorf("%s for service %q refers to a non-existent service %q", key, service.Name, targetService)
+		}
+		mode = "container:" + targetContainerName
+	}
+	return mode, nil
+}
+
+// joinNamespace sets a namespace flag on the container. If the mode joins the
+// namespace of another container, that container is marked as a dependency.
+func (g *Generator) joinNamespace(c *NixContainer, flag, mode string) {
+	c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("%s=%s", flag, mode))
+	if targetContainerName, ok := strings.CutPrefix(mode, "container:"); ok {
+		g.dependOnContainer(c, targetContainerName)
+	}
+}
+
+// Namespace and cgroup isolation modes.
+// https://docs.docker.com/reference/compose-file/services/#pid
+// https://docs.docker.com/reference/cli/docker/container/run/#pid
+// https://docs.podman.io/en/latest/markdown/podman-run.1.html#pid-mode
+func (g *Generator) handleNamespacesForService(service types.ServiceConfig, c *NixContainer) error {
+	namespaces := []struct {
+		key  string
+		flag string
+		mode string
+		// Modes supported by Docker. Podman supports everything Docker does,
+		// plus additional modes (e.g., "private", "ns:[path]", "keep-id").
+		dockerModes []string
+	}{
+		{"pid", "--pid", service.Pid, []string{"host", "container:"}},
+		{"uts", "--uts", service.Uts, []string{"host"}},
+		{"userns_mode", "--userns", service.UserNSMode, []string{"host"}},
+		{"cgroup", "--cgroupns", service.Cgroup, []string{"host", "private"}},
+	}
+	for _, ns := range namespaces {
+		mode, err := g.resolveNamespaceMode(service, ns.key, ns.mode)
+		if err != nil {
+			return err
+		}
+		if mode == "" {
+			continue
+		}
+		if g.Runtime == ContainerRuntimeDocker {
+			supported := slices.ContainsFunc(ns.dockerModes, func(m string) bool {
+				return mode == m || (strings.HasSuffix(m, ":") && strings.HasPrefix(mode, m))
+			})
+			if !supported {
+				if err := g.checkOrWarn("service %q: %s %q is not supported for %s runtime and will be ignored", service.Name, ns.key, mode, g.Runtime); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		g.joinNamespace(c, ns.flag, mode)
+	}
+
+	// https://docs.docker.com/reference/compose-file/services/#cgroup_parent
+	if service.CgroupParent != "" {
+		c.ExtraOptions = append(c.ExtraOptions, "--cgroup-parent="+service.CgroupParent)
+	}
+
+	return nil
+}
+
+// Resource constraints.
+// https://docs.docker.com/reference/compose-file/services/#cpu_count
+// https://docs.docker.com/reference/cli/docker/container/run/#memory
+// https://docs.podman.io/en/latest/markdown/podman-run.1.html#memory-m-number-unit
+func (g *Generator) handleResourcesForService(service types.ServiceConfig, c *NixContainer) error {
+	// compose-go rejects distinct values between the service-level keys and
+	// their deploy equivalents, so we only need to pick one of them.
+	memory := service.MemLimit
+	memoryReservation := service.MemReservation
+	cpus := service.CPUS
+	pids := service.PidsLimit
+
+	// Deploy resources configuration.
+	// https://docs.docker.com/compose/compose-file/deploy/#resources
+	if deploy := service.Deploy; deploy != nil {
+		if limits := deploy.Resources.Limits; limits != nil {
+			if limits.MemoryBytes != 0 {
+				memory = limits.MemoryBytes
+			}
+			// Name is misleading - this actually is the exact number passed in with "cpus".
+			if limits.NanoCPUs != 0 {
+				cpus = float32(limits.NanoCPUs)
+			}
+			if limits.Pids != 0 {
+				pids = limits.Pids
+			}
+		}
+		if reservations := deploy.Resources.Reservations; reservations != nil {
+			if reservations.MemoryBytes != 0 {
+				memoryReservation = reservations.MemoryBytes
+			}
+
+			// CPU reservation is a Docker Swarm option.
+
+			// CDI GPU support.
+			for _, device := range reservations.Devices {
+				driver := strings.ToLower(device.Driver)
+				if driver != "cdi" && driver != "nvidia" {
+					continue
+				}
+				if driver == "nvidia" {
+					// Pass in all GPUs in CDI format.
+					//
+					// TODO(aksiksi): Maybe we can do something better here?
+					c.ExtraOptions = append(c.ExtraOptions, "--device=nvidia.com/gpu=all")
+					if err := g.checkOrWarn("\"driver: nvidia\" is implicitly converted to CDI that matches all GPUs"); err != nil {
+						return err
+					}
+					continue
+				}
+				for _, deviceID := range device.IDs {
+					c.ExtraOptions = append(c.ExtraOptions, "--device="+deviceID)
+				}
+			}
+		}
+	}
+
+	if memory != 0 {
+		c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("--memory=%db", memory))
+	}
+	if memoryReservation != 0 {
+		c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("--memory-reservation=%db", memoryReservation))
+	}
+	if cpus != 0 {
+		c.ExtraOptions = append(c.ExtraOptions, "--cpus="+strconv.FormatFloat(float64(cpus), 'f', -1, 32))
+	}
+	if pids != 0 {
+		c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("--pids-limit=%d", pids))
+	}
+
+	// Both runtimes only treat the swap limit as a modifier of the memory limit.
+	if swap := service.MemSwapLimit; swap != 0 {
+		switch {
+		case memory == 0:
+			if err := g.checkOrWarn("service %q: 'memswap_limit' requires a memory limit and will be ignored", service.Name); err != nil {
+				return err
+			}
+		case swap == -1:
+			c.ExtraOptions = append(c.ExtraOptions, "--memory-swap=-1")
+		case swap < memory:
+			if err := g.checkOrWarn("service %q: 'memswap_limit' must be greater than or equal to the memory limit and will be ignored", service.Name); err != nil {
+				return err
+			}
+		default:
+			c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("--memory-swap=%db", swap))
+		}
+	}
+
+	// Podman fails to start the container if any of the following are set on
+	// a cgroups v2 host, which is the NixOS default. Docker merely discards
+	// them with a warning.
+	// https://docs.podman.io/en/latest/markdown/podman-run.1.html#memory-swappiness-number
+	// https://docs.podman.io/en/latest/markdown/podman-run.1.html#cpu-rt-period-microseconds
+	cgroupsV1Only := []struct {
+		key  string
+		flag string
+		v    int64
+		set  bool
+	}{
+		// A swappiness of 0 disables swapping, so it is only skipped when unset.
+		{"mem_swappiness", "--memory-swappiness", int64(service.MemSwappiness), g.serviceKeys[service.Name]["mem_swappiness"]},
+		{"cpu_rt_period", "--cpu-rt-period", service.CPURTPeriod, service.CPURTPeriod != 0},
+		{"cpu_rt_runtime", "--cpu-rt-runtime", service.CPURTRuntime, service.CPURTRuntime != 0},
+	}
+	for _, opt := range cgroupsV1Only {
+		if !opt.set {
+			continue
+		}
+		if g.Runtime == ContainerRuntimePodman {
+			if err := g.checkOrWarn("service %q: '%s' is only supported on cgroups v1 for %s runtime and will be ignored", service.Name, opt.key, g.Runtime); err != nil {
+				return err
+			}
+			continue
+		}
+		if opt.key == "mem_swappiness" && (opt.v < 0 || opt.v > 100) {
+			if err := g.checkOrWarn("service %q: 'mem_swappiness' must be between 0 and 100 and will be ignored", service.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("%s=%d", opt.flag, opt.v))
+	}
+
+	// https://docs.docker.com/reference/cli/docker/container/run/#cpu-shares
+	if service.CPUShares != 0 {
+		c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("--cpu-shares=%d", service.CPUShares))
+	}
+	// "cpus" is shorthand for a CPU period and quota, so the runtimes refuse to
+	// start a container that sets both.
+	cpuPeriod, cpuQuota := service.CPUPeriod, service.CPUQuota
+	if cpus != 0 && (cpuPeriod != 0 || cpuQuota != 0) {
+		if err := g.checkOrWarn("service %q: 'cpu_period' and 'cpu_quota' conflict with 'cpus' and will be ignored", service.Name); err != nil {
+			return err
+		}
+		cpuPeriod, cpuQuota = 0, 0
+	}
+	if cpuPeriod != 0 {
+		c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("--cpu-period=%d", cpuPeriod))
+	}
+	if cpuQuota != 0 {
+		c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("--cpu-quota=%d", cpuQuota))
+	}
+	if service.CPUSet != "" {
+		c.ExtraOptions = append(c.ExtraOptions, "--cpuset-cpus="+service.CPUSet)
+	}
+
+	if service.OomKillDisable {
+		c.ExtraOptions = append(c.ExtraOptions, "--oom-kill-disable")
+	}
+	if adj := service.OomScoreAdj; adj != 0 {
+		if adj < -1000 || adj > 1000 {
+			if err := g.checkOrWarn("service %q: 'oom_score_adj' must be between -1000 and 1000 and will be ignored", service.Name); err != nil {
+				return err
+			}
+		} else {
+			c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("--oom-score-adj=%d", adj))
+		}
+	}
+
+	// Block I/O.
+	// https://docs.docker.com/reference/compose-file/services/#blkio_config
+	// https://docs.docker.com/reference/cli/docker/container/run/#blkio-weight
+	// https://docs.podman.io/en/latest/markdown/podman-run.1.html#blkio-weight-weight
+	if blkio := service.BlkioConfig; blkio != nil {
+		if blkio.Weight != 0 {
+			c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("--blkio-weight=%d", blkio.Weight))
+		}
+		for _, d := range blkio.WeightDevice {
+			c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("--blkio-weight-device=%s:%d", d.Path, d.Weight))
+		}
+		throttles := []struct {
+			flag    string
+			devices []types.ThrottleDevice
+		}{
+			{"--device-read-bps", blkio.DeviceReadBps},
+			{"--device-read-iops", blkio.DeviceReadIOps},
+			{"--device-write-bps", blkio.DeviceWriteBps},
+			{"--device-write-iops", blkio.DeviceWriteIOps},
+		}
+		for _, t := range throttles {
+			for _, d := range t.devices {
+				c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("%s=%s:%d", t.flag, d.Path, d.Rate))
+			}
+		}
+	}
+
+	// https://docs.docker.com/reference/compose-file/services/#device_cgroup_rules
+	// https://docs.podman.io/en/latest/markdown/podman-run.1.html#device-cgroup-rule-type-major-minor-mode
+	for _, rule := range service.DeviceCgroupRules {
+		c.ExtraOptions = append(c.ExtraOptions, "--device-cgroup-rule="+rule)
+	}
+
+	return nil
+}
+
+// addPullUnit pulls the container's image in a dedicated oneshot service.
+//
+// If required is set, the container service requires the pull service.
+// Otherwise, the pull service must be triggered by a timer (i.e., interval > 0).
+func (g *Generator) addPullUnit(c *NixContainer, always, required bool, interval time.Duration) {
+	timeout := g.PullTimeout
+	if timeout == 0 {
+		timeout = defaultPullTimeout
+	}
+	c.Pull = &NixPull{
+		Runtime:       g.Runtime,
+		Image:         c.Image,
+		ContainerName: c.Name,
+		Always:        always,
+		Timeout:       timeout,
+		Retries:       g.PullRetries,
+		Interval:      interval,
+		Login:         c.Login,
+	}
+	if !g.NoCreateRootTarget {
+		c.Pull.PartOf = fmt.Sprintf("%s.target", rootTarget(g.Runtime, g.Project))
+	}
+	if interval > 0 && c.AutoStart {
+		c.Pull.WantedBy = append(c.Pull.WantedBy, "timers.target")
+		if c.Pull.PartOf != "" {
+			c.Pull.WantedBy = append(c.Pull.WantedBy, c.Pull.PartOf)
+		}
+	}
+	if required {
+		c.SystemdConfig.Unit.After = append(c.SystemdConfig.Unit.After, c.Pull.Unit())
+		c.SystemdConfig.Unit.Requires = append(c.SystemdConfig.Unit.Requires, c.Pull.Unit())
+	}
+}
+
+// handleScheduleForService turns the container into a oneshot job that is
+// started by a systemd timer instead of on boot.
+func (g *Generator) handleScheduleForService(service types.ServiceConfig, c *NixContainer) error {
+	// Oneshot services can only be restarted on failure.
+	if restart := c.SystemdConfig.Service.Options["Restart"]; restart != "no" && restart != "on-failure" {
+		if err := g.checkOrWarn("service %q: restart policy %q is not supported for scheduled containers and will be ignored", service.Name, restart); err != nil {
+			return err
+		}
+		c.SystemdConfig.Service.Set("Restart", "no")
+		delete(c.SystemdConfig.Service.Options, "RestartSec")
+		delete(c.SystemdConfig.Service.Options, "RestartSteps")
+		delete(c.SystemdConfig.Service.Options, "RestartMaxDelaySec")
+	}
+	c.SystemdConfig.Service.Set("Type", "oneshot")
+
+	// The NixOS module runs Podman containers detached, which would cause
+	// the unit to finish as soon as the container is started.
+	if g.Runtime == ContainerRuntimePodman {
+		c.ExtraOptions = append(c.ExtraOptions, "--detach=false")
+		slices.Sort(c.ExtraOptions)
+	}
+
+	// The timer is started instead of the container.
+	if c.AutoStart {
+		c.Schedule.WantedBy = append(c.Schedule.WantedBy, "timers.target")
+		if !g.NoCreateRootTarget {
+			c.Schedule.PartOf = append(c.Schedule.PartOf, fmt.Sprintf("%s.target", rootTarget(g.Runtime, g.Project)))
+			c.Schedule.WantedBy = append(c.Schedule.WantedBy, fmt.Sprintf("%s.target", rootTarget(g.Runtime, g.Project)))
+		}
+		c.AutoStart = false
+	}
+
+	return nil
+}
+
 func (g *Generator) buildNixContainer(service types.ServiceConfig, networkMap map[string]*NixNetwork, volumeMap map[string]*NixVolume) (*NixContainer, error) {
 	name := g.serviceToContainerName[service.Name]
 
 	c := &NixContainer{
-		Runtime:       g.Runtime,
-		Name:          name,
-		Image:         service.Image,
-		Labels:        service.Labels,
-		Ports:         portConfigsToPortStrings(service.Ports),
-		User:          service.User,
-		Volumes:       make(map[string]string),
-		SystemdConfig: NewNixContainerSystemdConfig(),
-		LogDriver:     "journald", // This is the NixOS default
-		AutoStart:     g.AutoStart,
+		Runtime:        g.Runtime,
+		Name:           name,
+		Image:          service.Image,
+		Labels:         service.Labels,
+		Ports:          portConfigsToPortStrings(service.Ports),
+		User:           service.User,
+		Volumes:        make(map[string]string),
+		SystemdConfig:  NewNixContainerSystemdConfig(),
+		LogDriver:      "journald", // This is the NixOS default
+		AutoStart:      g.AutoStart,
+		WaitForHealthy: g.WaitForHealthy,
 	}
 
 	if err := parseNixContainerLabels(c, g.SopsConfig); err != nil {
 		return nil, err
 	}
 
+	// Images built by Nix are loaded from the store, so they are never pulled.
+	pullsImage := service.Build == nil && c.NixImage == ""
+	if c.NixImage != "" && service.Image != "" {
+		if err := g.checkOrWarn("service %q: image %q is overridden by compose2nix.settings.nixImage and will be ignored", service.Name, service.Image); err != nil {
+			return nil, err
+		}
+	}
+	if c.NixImage != "" && service.PullPolicy != "" {
+		if err := g.checkOrWarn("service %q: pull_policy is not supported with compose2nix.settings.nixImage and will be ignored", service.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	// Point the image at a mirror, if any. This must be done before we
+	// resolve registry credentials.
+	if pullsImage && len(g.ImageRewriteRules) > 0 {
+		c.Image = rewriteImage(c.Image, g.ImageRewriteRules, true)
+	}
+
+	// Login to the image's registry if we have credentials for it.
+	if pullsImage && g.RegistryAuth != nil {
+		c.Login = g.RegistryAuth.Login(c.Image)
+	}
+
 	if g.IncludeEnvFiles || g.EnvFilesOnly {
 		// Env files provided via CLI.
 		c.EnvFiles = append(c.EnvFiles, g.EnvFiles...)
@@ -571,27 +1228,16 @@ func (g *Generator) buildNixContainer(service types.ServiceConfig, networkMap ma
 			// TODO(aksiksi): Can we even do anything for Docker?
 			c.ExtraOptions = append(c.ExtraOptions, "--network="+networkMode)
 			inBridgeNetwork = true
-		case strings.HasPrefix(networkMode, "service:"):
-			// Convert the Compose "service" network mode to a "container" network mode.
-			targetService := strings.Split(networkMode, ":")[1]
-			targetContainerName, ok := g.serviceToContainerName[targetService]
-			if !ok {
-				return nil, fmt.Errorf("network_mode for service %q refers to a non-existent service %q", service.Name, targetService)
-			}
-			c.ExtraOptions = append(c.ExtraOptions, "--network=container:"+targetContainerName)
-			if !slices.Contains(c.DependsOn, targetContainerName) {
-				c.DependsOn = append(c.DependsOn, targetContainerName)
-			}
-		case strings.HasPrefix(networkMode, "container:"):
-			// container:[name] mode is supported by both Docker and Podman.
-			// This container could be external, so we can't fail if it doesn't exist in this Compose
-			// project.
-			targetContainerName := strings.TrimSpace(strings.Split(networkMode, ":")[1])
-			c.ExtraOptions = append(c.ExtraOptions, "--network=container:"+targetContainerName)
-			// TODO(aksiksi): Should we even be doing this?
-			if !slices.Contains(c.DependsOn, targetContainerName) {
-				c.DependsOn = append(c.DependsOn, targetContainerName)
+		case strings.HasPrefix(networkMode, "service:"), strings.HasPrefix(networkMode, "container:"):
+			// The Compose "service" network mode is converted to a "container"
+			// network mode, which is supported by both Docker and Podman. The
+			// container could be external, so we can't fail if it doesn't exist
+			// in this Compose project.
+			mode, err := g.resolveNamespaceMode(service, "network_mode", networkMode)
+			if err != nil {
+				return nil, err
 			}
+			g.joinNamespace(c, "--network", mode)
 		default:
 			return nil, fmt.Errorf("unsupported network_mode: %s", networkMode)
 		}
@@ -697,6 +1343,11 @@ func (g *Generator) buildNixContainer(service types.ServiceConfig, networkMap ma
 		//
 		// See: https://docs.podman.io/en/latest/markdown/podman-run.1.html#network-alias-alias
 		c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("--network-alias=%s", service.Name))
+
+		// Aliases requested by other services through legacy links.
+		for _, alias := range g.serviceToLinkAliases[service.Name] {
+			c.ExtraOptions = append(c.ExtraOptions, "--network-alias="+alias)
+		}
 	}
 
 	for _, ip := range service.DNS {
@@ -773,9 +1424,83 @@ func (g *Generator) buildNixContainer(service types.ServiceConfig, networkMap ma
 		}
 	}
 
+	if err := g.handleExternalLinksForService(service, c); err != nil {
+		return nil, err
+	}
+
 	if service.Hostname != "" {
 		c.ExtraOptions = append(c.ExtraOptions, "--hostname="+service.Hostname)
 	}
+	if service.DomainName != "" {
+		if g.Runtime == ContainerRuntimePodman {
+			if err := g.checkOrWarn("service %q: 'domainname' is not supported for %s runtime and will be ignored", service.Name, g.Runtime); err != nil {
+				return nil, err
+			}
+		} else {
+			c.ExtraOptions = append(c.ExtraOptions, "--domainname="+service.DomainName)
+		}
+	}
+
+	// https://docs.docker.com/reference/compose-file/services/#read_only
+	if service.ReadOnly {
+		c.ExtraOptions = append(c.ExtraOptions, "--read-only")
+	}
+	if service.WorkingDir != "" {
+		c.ExtraOptions = append(c.ExtraOptions, "--workdir="+service.WorkingDir)
+	}
+	if service.Tty {
+		c.ExtraOptions = append(c.ExtraOptions, "--tty")
+	}
+	if service.StdinOpen {
+		c.ExtraOptions = append(c.ExtraOptions, "--interactive")
+	}
+	if service.Platform != "" {
+		c.ExtraOptions = append(c.ExtraOptions, "--platform="+service.Platform)
+	}
+
+	// https://docs.docker.com/reference/compose-file/services/#annotations
+	// https://docs.podman.io/en/latest/markdown/podman-run.1.html#annotation-key-value
+	c.ExtraOptions = append(c.ExtraOptions, mapToRepeatedKeyValFlag("--annotation", service.Annotations)...)
+
+	// https://docs.docker.com/reference/compose-file/services/#storage_opt
+	// https://docs.podman.io/en/latest/markdown/podman-run.1.html#storage-opt
+	c.ExtraOptions = append(c.ExtraOptions, mapToRepeatedKeyValFlag("--storage-opt", service.StorageOpt)...)
+
+	// Services with a build spec pass the pull policy to the build instead.
+	// https://docs.docker.com/reference/compose-file/services/#pull_policy
+	// https://docs.podman.io/en/latest/markdown/podman-run.1.html#pull-policy
+	if pullsImage && (service.PullPolicy != "" || g.PullUnits) {
+		switch policy := NewServicePullPolicy(service.PullPolicy); policy {
+		case ServicePullPolicyAlways, ServicePullPolicyMissing, ServicePullPolicyUnset:
+			if g.PullUnits && service.Image != "" {
+				// Pull the image in a separate unit so that a slow registry
+				// does not count towards the container's start timeout.
+				g.addPullUnit(c, policy == ServicePullPolicyAlways, true, 0)
+			} else if policy == ServicePullPolicyAlways {
+				c.ExtraOptions = append(c.ExtraOptions, "--pull=always")
+			} else if policy == ServicePullPolicyMissing {
+				c.ExtraOptions = append(c.ExtraOptions, "--pull=missing")
+			}
+		case ServicePullPolicyRefresh:
+			// Neither runtime supports pulling on an interval, so we pull the
+			// image from a timer instead. The new image is used on the next
+			// container restart.
+			_, interval, err := service.GetPullPolicy()
+			if err != nil {
+				return nil, fmt.Errorf("service %q: invalid pull_policy %q: %w", service.Name, service.PullPolicy, err)
+			}
+			g.addPullUnit(c, true, g.PullUnits, interval)
+			if !g.PullUnits {
+				c.ExtraOptions = append(c.ExtraOptions, "--pull=missing")
+			}
+		case ServicePullPolicyNever:
+			c.ExtraOptions = append(c.ExtraOptions, "--pull=never")
+		default:
+			if err := g.checkOrWarn("service %q: pull_policy %q is not supported without a build and will be ignored", service.Name, service.PullPolicy); err != nil {
+				return nil, err
+			}
+		}
+	}
 
 	// https://docs.docker.com/compose/compose-file/05-services/#group_add
 	// https://docs.docker.com/engine/reference/commandline/run/#group-add
@@ -787,15 +1512,14 @@ func (g *Generator) buildNixContainer(service types.ServiceConfig, networkMap ma
 	// https://docs.docker.com/compose/compose-file/05-services/#ipc
 	// https://docs.docker.com/engine/reference/run/#ipc-settings---ipc
 	// https://docs.podman.io/en/latest/markdown/podman-run.1.html#ipc-ipc
-	if ipc := strings.TrimSpace(service.Ipc); ipc != "" {
-		if strings.HasPrefix(ipc, "service:") {
-			// Convert the Compose "service" IPC mode to a "container" IPC mode.
-			// Note: compose-go automatically validates the service exists and adds it as a dependency.
-			targetService := strings.Split(ipc, ":")[1]
-			c.ExtraOptions = append(c.ExtraOptions, "--ipc=container:"+g.serviceToContainerName[targetService])
-		} else {
-			c.ExtraOptions = append(c.ExtraOptions, "--ipc="+ipc)
-		}
+	if ipc, err := g.resolveNamespaceMode(service, "ipc", service.Ipc); err != nil {
+		return nil, err
+	} else if ipc != "" {
+		g.joinNamespace(c, "--ipc", ipc)
+	}
+
+	if err := g.handleNamespacesForService(service, c); err != nil {
+		return nil, err
 	}
 
 	// https://docs.docker.com/compose/compose-file/05-services/#sysctls
@@ -832,49 +1556,53 @@ func (g *Generator) buildNixContainer(service types.ServiceConfig, networkMap ma
 	if err := parseHealthCheck(c, service, g.Runtime); err != nil {
 		return nil, err
 	}
+	parseServiceHooks(c, service, g.Runtime)
 
-	// Deploy resources configuration.
-	// https://docs.docker.com/compose/compose-file/deploy/#resources
-	if deploy := service.Deploy; deploy != nil {
-		if limits := deploy.Resources.Limits; limits != nil {
-			if limits.MemoryBytes != 0 {
-				c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("--memory=%db", limits.MemoryBytes))
+	// Act on unhealthy containers. Without this, a hung container keeps running
+	// and the systemd restart policy never kicks in.
+	if c.HealthOnFailure != "" && c.HealthOnFailure != "none" {
+		if !hasHealthCheck(service) {
+			if err := g.checkOrWarn("service %q: compose2nix.settings.healthOnFailure is set without a healthcheck and will be ignored", service.Name); err != nil {
+				return nil, err
 			}
-			// Name is misleading - this actually is the exact number passed in with "cpus".
-			if limits.NanoCPUs != 0 {
-				c.ExtraOptions = append(c.ExtraOptions, "--cpus="+strconv.FormatFloat(float64(limits.NanoCPUs), 'f', -1, 32))
+			c.HealthOnFailure = ""
+		} else if g.Runtime == ContainerRuntimePodman {
+			// https://docs.podman.io/en/latest/markdown/podman-run.1.html#health-on-failure-action
+			c.ExtraOptions = append(c.ExtraOptions, "--health-on-failure="+c.HealthOnFailure)
+		} else {
+			// Docker has no equivalent, so we check the health status on a timer
+			// at the same interval as the healthcheck itself.
+			c.HealthCheckInterval = defaultHealthCheckInterval
+			if interval := service.HealthCheck.Interval; interval != nil {
+				c.HealthCheckInterval = time.Duration(*interval)
 			}
 		}
-		if reservations := deploy.Resources.Reservations; reservations != nil {
-			if reservations.MemoryBytes != 0 {
-				c.ExtraOptions = append(c.ExtraOptions, fmt.Sprintf("--memory-reservation=%db", reservations.MemoryBytes))
-			}
-
-			// CPU reservation is a Docker Swarm option.
+	}
 
-			// CDI GPU support.
-			for _, device := range reservations.Devices {
-				driver := strings.ToLower(device.Driver)
-				if driver != "cdi" && driver != "nvidia" {
-					continue
-				}
-				if driver == "nvidia" {
-					// Pass in all GPUs in CDI format.
-					//
-					// TODO(aksiksi): Maybe we can do something better here?
-					c.ExtraOptions = append(c.ExtraOptions, "--device=nvidia.com/gpu=all")
-					if err := g.checkOrWarn("\"driver: nvidia\" is implicitly converted to CDI that matches all GPUs"); err != nil {
-						return nil, err
-					}
-					continue
-				}
-				for _, deviceID := range device.IDs {
-					c.ExtraOptions = append(c.ExtraOptions, "--device="+deviceID)
-				}
+	// Only consider the container started once it is healthy. This ensures
+	// that dependent containers are not started too early.
+	if c.WaitForHealthy && hasHealthCheck(service) && c.Schedule == nil {
+		switch g.Runtime {
+		case ContainerRuntimePodman:
+			// https://docs.podman.io/en/latest/markdown/podman-run.1.html#sdnotify-container-conmon-healthy-ignore
+			c.ExtraOptions = append(c.ExtraOptions, "--sdnotify=healthy")
+			c.SystemdConfig.Service.Set("Type", "notify")
+		case ContainerRuntimeDocker:
+			// Docker has no systemd integration, so poll the health status instead.
+			timeout := g.WaitForHealthyTimeout
+			if timeout == 0 {
+				timeout = defaultWaitForHealthyTimeout
 			}
+			c.PostStart = append(c.PostStart, fmt.Sprintf(
+				`until [ "$(%[1]s inspect --format '{{.State.Health.Status}}' %[2]s 2>/dev/null)" = healthy ]; do if [ "$SECONDS" -ge %[3]d ]; then echo "timed out waiting for %[2]s to become healthy" >&2; exit 1; fi; sleep 1; done`,
+				g.Runtime, shellQuote(c.Name), int(timeout.Seconds())))
 		}
 	}
 
+	if err := g.handleResourcesForService(service, c); err != nil {
+		return nil, err
+	}
+
 	// Restart policy.
 	if err := c.SystemdConfig.ParseRestartPolicy(&service, g.Runtime); err != nil {
 		return nil, err
@@ -899,6 +1627,12 @@ func (g *Generator) buildNixContainer(service types.ServiceConfig, networkMap ma
 	slices.Sort(c.ExtraOptions)
 	slices.Sort(c.Networks)
 
+	if c.Schedule != nil {
+		if err := g.handleScheduleForService(service, c); err != nil {
+			return nil, err
+		}
+	}
+
 	// Add systemd dependency on root target.
 	//
 	// NOTE(aksiksi): We must check auto-start here because the root target
@@ -930,20 +1664,75 @@ func (g *Generator) buildNixContainer(service types.ServiceConfig, networkMap ma
 	}
 
 	// systemd configs provided via labels always override everything else.
-	if err := c.SystemdConfig.ParseSystemdLabels(&service); err != nil {
+	warnings, err := c.SystemdConfig.ParseSystemdLabels(&service)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.checkOrWarnAll(fmt.Sprintf("service %q", service.Name), warnings); err != nil {
 		return nil, err
 	}
 
 	return c, nil
 }
 
-func (g *Generator) parseServiceBuild(service types.ServiceConfig, c *NixContainer) (*NixBuild, error) {
+// Same prefixes as Compose, plus local repos.
+var gitContextPrefixes = []string{"https://", "http://", "git://", "ssh://", "github.com/", "git@", "file://"}
+
+// Git servers only resolve full commit SHAs when fetching, so refs that look
+// like abbreviated SHAs cannot be fetched.
+var gitAbbreviatedSHARegexp = regexp.MustCompile(`^[0-9a-f]{4,39}$`)
+
+func isGitContext(cx string) bool {
+	for _, prefix := range gitContextPrefixes {
+		if strings.HasPrefix(cx, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGitContext splits a Git build context into the repo URL, ref and
+// subdirectory.
+//
+// See: https://docs.docker.com/build/building/context/#url-fragments
+func parseGitContext(cx string) (url, ref, subdir string, err error) {
+	url, fragment, _ := strings.Cut(cx, "#")
+	ref, subdir, _ = strings.Cut(fragment, ":")
+	if strings.HasPrefix(url, "github.com/") {
+		url = "https://" + url
+	}
+	if subdir != "" {
+		subdir = path.Clean(subdir)
+		if path.IsAbs(subdir) || subdir == ".." || strings.HasPrefix(subdir, "../") {
+			return "", "", "", fmt.Errorf("build context subdirectory %q must be within the repo", subdir)
+		}
+	}
+	return url, ref, subdir, nil
+}
+
+func (g *Generator) parseServiceBuild(service types.ServiceConfig, c *NixContainer, networkMap map[string]*NixNetwork, secrets types.Secrets) (*NixBuild, error) {
 	cx := service.Build.Context
 	isGitRepo := false
+	var gitRef, gitSubdir string
 
-	if strings.HasPrefix(cx, "http") {
+	if isGitContext(cx) {
 		// Process this as a Git repo.
 		isGitRepo = true
+		var err error
+		cx, gitRef, gitSubdir, err = parseGitContext(cx)
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %w", service.Name, err)
+		}
+		if c.BuildRef != "" {
+			gitRef = c.BuildRef
+		}
+		if gitAbbreviatedSHARegexp.MatchString(gitRef) {
+			if err := g.checkOrWarn("service %q: Git ref %q looks like an abbreviated commit SHA, which cannot be fetched - use the full 40-character SHA instead", service.Name, gitRef); err != nil {
+				return nil, err
+			}
+		}
+	} else if c.BuildRef != "" {
+		return nil, fmt.Errorf("service %q: compose2nix.settings.buildRef is only supported for Git build contexts", service.Name)
 	} else if !path.IsAbs(cx) {
 		cx = path.Join(g.rootPath, cx)
 	}
@@ -975,16 +1764,146 @@ func (g *Generator) parseServiceBuild(service types.ServiceConfig, c *NixContain
 		c.Image = imageName
 	}
 
+	// Build args are commonly used to set the base image (e.g., "FROM
+	// ${BASE_IMAGE}"). As build args can hold any value, we only normalize bare
+	// image names for args named like images.
+	args := service.Build.Args
+	if len(g.ImageRewriteRules) > 0 && len(args) > 0 {
+		args = make(map[string]*string, len(service.Build.Args))
+		for name, arg := range service.Build.Args {
+			if arg != nil {
+				rewritten := rewriteImage(*arg, g.ImageRewriteRules, strings.HasSuffix(strings.ToUpper(name), "IMAGE"))
+				arg = &rewritten
+			}
+			args[name] = arg
+		}
+	}
+
 	b := &NixBuild{
-		Runtime:       g.Runtime,
-		Context:       cx,
-		PullPolicy:    NewServicePullPolicy(service.PullPolicy),
-		IsGitRepo:     isGitRepo,
-		Args:          service.Build.Args,
-		Tags:          tags,
-		Dockerfile:    service.Build.Dockerfile,
-		ContainerName: c.Name,
+		Runtime:          g.Runtime,
+		Context:          cx,
+		PullPolicy:       NewServicePullPolicy(service.PullPolicy),
+		IsGitRepo:        isGitRepo,
+		GitRef:           gitRef,
+		GitSubdir:        gitSubdir,
+		Args:             args,
+		Tags:             tags,
+		Dockerfile:       service.Build.Dockerfile,
+		DockerfileInline: service.Build.DockerfileInline,
+		ContainerName:    c.Name,
+		SystemdConfig:    NewNixContainerSystemdConfig(),
+	}
+
+	// https://docs.docker.com/reference/compose-file/build/
+	// https://docs.podman.io/en/latest/markdown/podman-build.1.html
+	build := service.Build
+	b.Target = build.Target
+	// Podman can only build multiple platforms into a manifest list, which
+	// cannot be run directly, and Docker's default image store cannot hold
+	// multi-platform images.
+	// https://docs.podman.io/en/latest/markdown/podman-build.1.html#platform-os-arch-variant
+	// https://docs.docker.com/build/building/multi-platform/
+	if len(build.Platforms) > 1 {
+		if err := g.checkOrWarn("service %q: building for multiple platforms is not supported for %s runtime and 'build.platforms' will be ignored", service.Name, g.Runtime); err != nil {
+			return nil, err
+		}
+	} else {
+		b.Platforms = build.Platforms
+	}
+	b.CacheFrom = build.CacheFrom
+	b.CacheTo = build.CacheTo
+	if g.Runtime == ContainerRuntimePodman {
+		if err := g.translatePodmanBuildOptions(service, b); err != nil {
+			return nil, err
+		}
+	}
+	b.Labels = stripSystemdLabels(build.Labels)
+	b.ShmSize = int64(build.ShmSize)
+	b.NoCache = build.NoCache
+	b.Pull = build.Pull
+	for hostname, ips := range build.ExtraHosts {
+		for _, ip := range ips {
+			b.ExtraHosts = append(b.ExtraHosts, fmt.Sprintf("%s:%s", hostname, ip))
+		}
+	}
+	slices.Sort(b.ExtraHosts)
+	// Local paths are already resolved by the Compose loader.
+	for name, cx := range build.AdditionalContexts {
+		b.AdditionalContexts = append(b.AdditionalContexts, fmt.Sprintf("%s=%s", name, cx))
+	}
+	slices.Sort(b.AdditionalContexts)
+	switch network := build.Network; network {
+	case "", "default", "host", "none":
+		b.Network = network
+	default:
+		n, ok := networkMap[network]
+		if !ok {
+			return nil, fmt.Errorf("service %q: build network %q not found", service.Name, network)
+		}
+		// BuildKit, which Docker uses by default, only supports the built-in
+		// network modes.
+		// https://docs.docker.com/reference/cli/docker/buildx/build/#network
+		if g.Runtime == ContainerRuntimeDocker {
+			if err := g.checkOrWarn("service %q: build network %q is not supported for %s runtime and will be ignored", service.Name, network, g.Runtime); err != nil {
+				return nil, err
+			}
+			break
+		}
+		b.Network = n.Name
+		if !n.External {
+			b.SystemdConfig.Unit.After = append(b.SystemdConfig.Unit.After, n.Unit())
+			b.SystemdConfig.Unit.Requires = append(b.SystemdConfig.Unit.Requires, n.Unit())
+		}
+	}
+
+	if err := g.handleBuildSecrets(service, b, secrets); err != nil {
+		return nil, err
+	}
+
+	// https://docs.docker.com/reference/compose-file/build/#ssh
+	for _, key := range build.SSH {
+		// Without a key path, the SSH agent socket is forwarded. Build services
+		// do not run with an agent, so only keys are supported.
+		if key.Path == "" {
+			if err := g.checkOrWarn("service %q: build ssh %q requires an SSH agent, which is not available to build services, and will be ignored - use %q instead", service.Name, key.ID, key.ID+"=/path/to/key"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		b.SSH = append(b.SSH, fmt.Sprintf("%s=%s", key.ID, key.Path))
+	}
+	slices.Sort(b.SSH)
+
+	triggers, err := g.buildRestartTriggers(b)
+	if err != nil {
+		return nil, fmt.Errorf("service %q: %w", service.Name, err)
+	}
+	b.RestartTriggers = triggers
+
+	// Build unit options are applied in order of precedence: defaults, flags,
+	// service labels and then build labels.
+	b.SystemdConfig.Service.Set("TimeoutSec", int(defaultBuildTimeout.Seconds()))
+	for k, v := range g.BuildServiceConfig.Options {
+		b.SystemdConfig.Service.Set(k, v)
+	}
+	warnings, err := b.SystemdConfig.Service.ParseSystemdBuildLabels(&service)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.checkOrWarnAll(fmt.Sprintf("service %q", service.Name), warnings); err != nil {
+		return nil, err
+	}
+	warnings, err = b.SystemdConfig.parseSystemdLabels(build.Labels, false)
+	if err != nil {
+		return nil, fmt.Errorf("service %q: build: %w", service.Name, err)
+	}
+	if err := g.checkOrWarnAll(fmt.Sprintf("service %q: build", service.Name), warnings); err != nil {
+		return nil, err
+	}
+	if err := b.SystemdConfig.checkReservedOptions(reservedBuildServiceOptions); err != nil {
+		return nil, fmt.Errorf("service %q: build: %w", service.Name, err)
 	}
+	b.SystemdConfig.Sort()
 
 	if g.IncludeBuild {
 		// Add dependency on build systemd service.
@@ -998,20 +1917,186 @@ func (g *Generator) parseServiceBuild(service types.ServiceConfig, c *NixContain
 	return b, nil
 }
 
+// handleBuildSecrets passes the build's secrets to the build command. File
+// secrets are passed as-is, while external secrets are looked up in the sops
+// config by name.
+//
+// https://docs.docker.com/reference/compose-file/build/#secrets
+// https://docs.podman.io/en/latest/markdown/podman-build.1.html#secret-id-id-src-path
+func (g *Generator) handleBuildSecrets(service types.ServiceConfig, b *NixBuild, secrets types.Secrets) error {
+	for _, ref := range service.Build.Secrets {
+		secret, ok := secrets[ref.Source]
+		if !ok {
+			return fmt.Errorf("service %q: build secret %q not found", service.Name, ref.Source)
+		}
+		id := ref.Source
+		if ref.Target != "" {
+			id = ref.Target
+		}
+		switch {
+		case secret.File != "":
+			b.Secrets = append(b.Secrets, NixBuildSecret{ID: id, Src: secret.File})
+		case bool(secret.External):
+			if g.SopsConfig == nil {
+				return fmt.Errorf("service %q: external build secret %q requires a sops config", service.Name, ref.Source)
+			}
+			if !g.SopsConfig.HasSecret(secret.Name) {
+				return fmt.Errorf("sops secret %q not found in sops config file %q", secret.Name, g.SopsConfig.FilePath)
+			}
+			b.Secrets = append(b.Secrets, NixBuildSecret{ID: id, SopsSecret: secret.Name})
+		default:
+			if err := g.checkOrWarn("service %q: build secret %q must be a file or an external sops secret and will be ignored", service.Name, ref.Source); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// buildRestartTriggers returns the restart triggers for a local build context.
+// The Dockerfile is included if it lives outside of the context. Git contexts
+// are pinned by ref, so they do not need triggers.
+func (g *Generator) buildRestartTriggers(b *NixBuild) ([]string, error) {
+	if g.BuildTriggers == BuildTriggerModeNone || b.IsGitRepo {
+		return nil, nil
+	}
+	paths := []string{b.Context}
+	if b.DockerfileInline == "" {
+		dockerfile := b.Dockerfile
+		if dockerfile == "" {
+			dockerfile = "Dockerfile"
+		}
+		if !path.IsAbs(dockerfile) {
+			dockerfile = path.Join(b.Context, dockerfile)
+		}
+		if !isSubPath(b.Context, dockerfile) {
+			paths = append(paths, dockerfile)
+		}
+	}
+
+	switch g.BuildTriggers {
+	case BuildTriggerModeHash:
+		h := sha256.New()
+		for _, p := range paths {
+			if err := hashPath(h, p); err != nil {
+				return nil, fmt.Errorf("failed to hash build context: %w", err)
+			}
+		}
+		return []string{fmt.Sprintf("%q", hex.EncodeToString(h.Sum(nil)))}, nil
+	case BuildTriggerModePath:
+		outputDir := g.OutputDir
+		if outputDir == "" {
+			outputDir = g.rootPath
+		}
+		outputDir, err := filepath.Abs(outputDir)
+		if err != nil {
+			return nil, err
+		}
+		var triggers []string
+		for _, p := range paths {
+			p, err := filepath.Abs(p)
+			if err != nil {
+				return nil, err
+			}
+			rel, err := filepath.Rel(outputDir, p)
+			if err != nil {
+				return nil, fmt.Errorf("failed to reference build context %q as a Nix path: %w", p, err)
+			}
+			triggers = append(triggers, nixRelativePath(rel))
+		}
+		return triggers, nil
+	default:
+		panic("Unreachable")
+	}
+}
+
+// isSubPath returns true if p is dir or is nested under it.
+func isSubPath(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// hashPath writes the names, executable bits and contents of all files under p
+// to h in a deterministic order. Git metadata is skipped.
+func hashPath(h io.Writer, p string) error {
+	return filepath.WalkDir(p, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(p, name)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		// Only the executable bit is tracked by Git, so ignore the rest of the
+		// mode as it depends on the umask.
+		fmt.Fprintf(h, "%s\x00%t\x00", filepath.ToSlash(rel), info.Mode()&0o111 != 0)
+		if d.Type()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(name)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s\x00", target)
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(h, f)
+		return err
+	})
+}
+
+var nixPathRegexp = regexp.MustCompile(`^[a-zA-Z0-9._+\-/]+$`)
+
+// nixRelativePath returns a Nix path expression for a path relative to the
+// generated Nix file.
+func nixRelativePath(rel string) string {
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		return "./."
+	}
+	if nixPathRegexp.MatchString(rel) && !strings.HasSuffix(rel, "/") {
+		if strings.HasPrefix(rel, "../") {
+			return rel
+		}
+		return "./" + rel
+	}
+	return fmt.Sprintf("(./. + %q)", "/"+rel)
+}
+
 func (g *Generator) buildNixContainers(composeProject *types.Project, networkMap map[string]*NixNetwork, volumeMap map[string]*NixVolume) (containers []*NixContainer, builds []*NixBuild, _ error) {
 	for _, s := range composeProject.Services {
 		if g.ServiceInclude != nil && !g.ServiceInclude.MatchString(s.Name) {
 			log.Printf("Skipping service %q due to include regex %q", s.Name, g.ServiceInclude.String())
 			continue
 		}
+		if err := g.checkUnsupportedServiceKeys(s); err != nil {
+			return nil, nil, err
+		}
 		c, err := g.buildNixContainer(s, networkMap, volumeMap)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to build container for service %q: %w", s.Name, err)
 		}
 		containers = append(containers, c)
 
-		if s.Build != nil {
-			b, err := g.parseServiceBuild(s, c)
+		// Nix-built images replace the build.
+		if s.Build != nil && c.NixImage == "" {
+			b, err := g.parseServiceBuild(s, c, networkMap, composeProject.Secrets)
 			if err != nil {
 				return nil, nil, fmt.Errorf("failed to parse build for service %q: %w", s.Name, err)
 			}
@@ -1041,24 +2126,36 @@ func (g *Generator) volumeNameToService(name string) string {
 	return fmt.Sprintf("%s-volume-%s.service", g.Runtime, name)
 }
 
-func (g *Generator) buildNixNetworks(composeProject *types.Project) ([]*NixNetwork, map[string]*NixNetwork) {
+func (g *Generator) buildNixNetworks(composeProject *types.Project) ([]*NixNetwork, map[string]*NixNetwork, error) {
 	networkMap := make(map[string]*NixNetwork)
 
 	var networks []*NixNetwork
 	for name, network := range composeProject.Networks {
 		n := &NixNetwork{
-			Runtime:      g.Runtime,
-			Name:         g.Project.With(name),
-			OriginalName: name,
-			Driver:       network.Driver,
-			DriverOpts:   network.DriverOpts,
-			External:     bool(network.External),
-			Labels:       network.Labels,
+			Runtime:       g.Runtime,
+			Name:          g.Project.With(name),
+			OriginalName:  name,
+			Driver:        network.Driver,
+			DriverOpts:    network.DriverOpts,
+			External:      bool(network.External),
+			Labels:        stripSystemdLabels(network.Labels),
+			SystemdConfig: NewNixContainerSystemdConfig(),
 		}
 
 		if network.Name != "" {
 			n.Name = network.Name
 		}
+		warnings, err := n.SystemdConfig.parseSystemdLabels(network.Labels, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("network %q: %w", name, err)
+		}
+		if err := g.checkOrWarnAll(fmt.Sprintf("network %q", name), warnings); err != nil {
+			return nil, nil, err
+		}
+		if err := n.SystemdConfig.checkReservedOptions(reservedResourceServiceOptions); err != nil {
+			return nil, nil, fmt.Errorf("network %q: %w", name, err)
+		}
+		n.SystemdConfig.Sort()
 		networkMap[name] = n
 
 		if network.Internal {
@@ -1098,26 +2195,37 @@ func (g *Generator) buildNixNetworks(composeProject *types.Project) ([]*NixNetwo
 	slices.SortFunc(networks, func(n1, n2 *NixNetwork) int {
 		return cmp.Compare(n1.Name, n2.Name)
 	})
-	return networks, networkMap
+	return networks, networkMap, nil
 }
 
-func (g *Generator) buildNixVolumes(composeProject *types.Project) ([]*NixVolume, map[string]*NixVolume) {
+func (g *Generator) buildNixVolumes(composeProject *types.Project) ([]*NixVolume, map[string]*NixVolume, error) {
 	volumeMap := make(map[string]*NixVolume)
 	var volumes []*NixVolume
 	for name, volume := range composeProject.Volumes {
 		v := &NixVolume{
-			Runtime:      g.Runtime,
-			Name:         g.Project.With(name),
-			Driver:       volume.Driver,
-			DriverOpts:   volume.DriverOpts,
-			External:     bool(volume.External),
-			Labels:       volume.Labels,
-			RemoveOnStop: g.RemoveVolumes,
+			Runtime:       g.Runtime,
+			Name:          g.Project.With(name),
+			Driver:        volume.Driver,
+			DriverOpts:    volume.DriverOpts,
+			External:      bool(volume.External),
+			Labels:        stripSystemdLabels(volume.Labels),
+			RemoveOnStop:  g.RemoveVolumes,
+			SystemdConfig: NewNixContainerSystemdConfig(),
 		}
 
 		if volume.Name != "" {
 			v.Name = volume.Name
 		}
+		warnings, err := v.SystemdConfig.parseSystemdLabels(volume.Labels, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("volume %q: %w", name, err)
+		}
+		if err := g.checkOrWarnAll(fmt.Sprintf("volume %q", name), warnings); err != nil {
+			return nil, nil, err
+		}
+		if err := v.SystemdConfig.checkReservedOptions(reservedResourceServiceOptions); err != nil {
+			return nil, nil, fmt.Errorf("volume %q: %w", name, err)
+		}
 		volumeMap[name] = v
 
 		volumes = append(volumes, v)
@@ -1133,9 +2241,12 @@ func (g *Generator) buildNixVolumes(composeProject *types.Project) ([]*NixVolume
 				log.Printf("Volume path %q is not absolute; skipping systemd mount dependency for volume %q", path, name)
 				continue
 			}
-			volume.RequiresMountsFor = append(volume.RequiresMountsFor, path)
+			volume.SystemdConfig.Unit.RequiresMountsFor = append(volume.SystemdConfig.Unit.RequiresMountsFor, path)
 		}
 	}
+	for _, volume := range volumes {
+		volume.SystemdConfig.Sort()
+	}
 
-	return volumes, volumeMap
+	return volumes, volumeMap, nil
 }
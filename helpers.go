@@ -42,6 +42,11 @@ func sliceToStringArray(s []string) string {
 	return fmt.Sprintf("[%s]", strings.Join(s, ", "))
 }
 
+// shellQuote quotes the given string for use as a single POSIX shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 // ReadEnvFiles reads the given set of env files into a list of KEY=VAL entries.
 //
 // If mergeWithEnv is set, the running env is merged with the provided env files. Any
@@ -25,6 +25,7 @@ var project = flag.String("project", "", "project name used as a prefix for gene
 var serviceInclude = flag.String("service_include", "", "regex pattern for services to include.")
 var envFiles = flag.String("env_files", "", "one or more comma-separated paths to .env file(s).")
 var rootPath = flag.String("root_path", "", "absolute path to use as the root for any relative paths in the Compose file (e.g., volumes, env files). defaults to the current working directory.")
+var imageRewrite = flag.String("image_rewrite", "", "one or more comma-separated image rewrite rules of the form \"from=to\" (e.g., \"docker.io=mirror.example.com/dockerhub\"). bare Docker Hub image names are normalized before matching (e.g., \"postgres\" -> \"docker.io/library/postgres\").")
 var includeEnvFiles = flag.Bool("include_env_files", false, "include env files in the NixOS container definition.")
 var envFilesOnly = flag.Bool("env_files_only", false, "only use env file(s) in the NixOS container definitions.")
 var ignoreMissingEnvFiles = flag.Bool("ignore_missing_env_files", false, "if set, missing env files will be ignored.")
@@ -38,6 +39,8 @@ var useUpheldBy = flag.Bool("use_upheld_by", false, "if set, upheldBy will be us
 var removeVolumes = flag.Bool("remove_volumes", false, "if set, volumes will be removed on systemd service stop.")
 var createRootTarget = flag.Bool("create_root_target", true, "if set, a root systemd target will be created, which when stopped tears down all resources.")
 var defaultStopTimeout = flag.Duration("default_stop_timeout", defaultSystemdStopTimeout, "default stop timeout for generated container services.")
+var buildServiceOptions = flag.String("build_service_options", "", `one or more comma-separated systemd service options of the form "key=value" applied to all build services (e.g., "TimeoutSec=30min,CPUQuota=50%,Nice=10"). these can be overridden per-service using "compose2nix.systemd.build.*" labels.`)
+var buildTriggers = flag.String("build_triggers", "", `if set, build services are restarted when their local build context changes. one of: ["hash", "path"]. "hash" embeds a content hash of the context computed at generation time. "path" references the context as a Nix path relative to the output file, which copies it to the Nix store.`)
 var build = flag.Bool("build", false, "if set, generated container build systemd services will be enabled.")
 var writeNixSetup = flag.Bool("write_nix_setup", true, "if true, Nix setup code is written to output (runtime, DNS, autoprune, etc.)")
 var autoFormat = flag.Bool("auto_format", false, `if true, Nix output will be formatted using "nixfmt" (must be present in $PATH).`)
@@ -45,6 +48,12 @@ var optionPrefix = flag.String("option_prefix", "", "Prefix for the option. If e
 var enableOption = flag.Bool("enable_option", false, "generate a NixOS module option. this allows you to enable or disable the generated module from within your NixOS config. by default, the option will be named \"options.[project_name]\", but you can add a prefix using the \"option_prefix\" flag.")
 var warningsAsErrors = flag.Bool("warnings_as_errors", false, "if set, treat generator warnings as hard errors.")
 var sopsFile = flag.String("sops_file", "", "path to encrypted secrets YAML file (e.g., secrets.yaml). when set, secrets defined in compose services using \"compose2nix.sops.secret=secret1,secret2\" labels will be added as environmentFiles.")
+var registryAuth = flag.String("registry_auth", "", "path to a YAML file mapping registry hosts to a username and either a \"passwordFile\" path or a \"sopsSecret\" name. containers with images on these registries will login before pulling.")
+var waitForHealthy = flag.Bool("wait_for_healthy", false, "if set, container services with a healthcheck are only considered started once the container is healthy. this can be overridden per-service using the \"compose2nix.settings.waitForHealthy\" label.")
+var waitForHealthyTimeout = flag.Duration("wait_for_healthy_timeout", defaultWaitForHealthyTimeout, "maximum time to wait for a container to become healthy. only applies to the Docker runtime.")
+var pullUnits = flag.Bool("pull_units", false, "if set, container images are pulled by a separate systemd service per container. this decouples slow image pulls from container start.")
+var pullTimeout = flag.Duration("pull_timeout", defaultPullTimeout, "maximum time for a pull service to pull an image, including retries. only applies if -pull_units is set.")
+var pullRetries = flag.Int("pull_retries", 3, "number of times a pull service retries a failed pull, with exponential backoff. only applies if -pull_units is set.")
 var version = flag.Bool("version", false, "display version and exit")
 
 type OsGetWd struct{}
@@ -86,6 +95,22 @@ func main() {
 		log.Fatalf("Invalid --runtime: %q", *runtime)
 	}
 
+	buildTriggerMode, err := NewBuildTriggerMode(*buildTriggers)
+	if err != nil {
+		log.Fatalf("Invalid -build_triggers: %v", err)
+	}
+
+	buildServiceConfig, warnings, err := ParseSystemdOptions(*buildServiceOptions)
+	if err != nil {
+		log.Fatalf("Failed to parse -build_service_options: %v", err)
+	}
+	for _, w := range warnings {
+		if *warningsAsErrors {
+			log.Fatalf("Failed to parse -build_service_options: %s", w)
+		}
+		log.Printf("warning: -build_service_options: %s", w)
+	}
+
 	var serviceIncludeRegexp *regexp.Regexp
 	if *serviceInclude != "" {
 		pat, err := regexp.Compile(*serviceInclude)
@@ -103,6 +128,20 @@ func main() {
 		}
 	}
 
+	imageRewriteRules, err := ParseImageRewriteRules(*imageRewrite)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var registryAuthConf RegistryAuth
+	if *registryAuth != "" {
+		var err error
+		registryAuthConf, err = LoadRegistryAuth(*registryAuth, sopsConf)
+		if err != nil {
+			log.Fatalf("Failed to load registry auth file: %v", err)
+		}
+	}
+
 	start := time.Now()
 	g := Generator{
 		Project:                 NewProject(*project),
@@ -132,6 +171,16 @@ func main() {
 		EnableOption:            *enableOption,
 		SopsConfig:              sopsConf,
 		WarningsAsErrors:        *warningsAsErrors,
+		WaitForHealthy:          *waitForHealthy,
+		WaitForHealthyTimeout:   *waitForHealthyTimeout,
+		PullUnits:               *pullUnits,
+		PullTimeout:             *pullTimeout,
+		PullRetries:             *pullRetries,
+		RegistryAuth:            registryAuthConf,
+		ImageRewriteRules:       imageRewriteRules,
+		BuildTriggers:           buildTriggerMode,
+		BuildServiceConfig:      buildServiceConfig,
+		OutputDir:               path.Dir(*output),
 	}
 	containerConfig, err := g.Run(ctx)
 	if err != nil {
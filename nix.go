@@ -3,8 +3,12 @@ package main
 import (
 	"fmt"
 	"io"
+	"maps"
+	"regexp"
+	"slices"
 	"strings"
 	"text/template"
+	"time"
 )
 
 // Compose V2 uses "-" for container names: https://docs.docker.com/compose/migrate/#service-container-names
@@ -12,6 +16,8 @@ import (
 // Volumes and networks still use "_", but we'll ignore that here: https://github.com/docker/compose/issues/9618
 const DefaultProjectSeparator = "-"
 
+var nonAlphaNumRegexp = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
 type ContainerRuntime int
 
 const (
@@ -70,6 +76,8 @@ type NixNetwork struct {
 	IpamDriver   string
 	IpamConfigs  []IpamConfig
 	ExtraOptions []string
+	// Set using "compose2nix.systemd.*" labels on the network.
+	SystemdConfig *NixContainerSystemdConfig
 }
 
 func (n *NixNetwork) Unit() string {
@@ -116,14 +124,15 @@ func (n *NixNetwork) Command() string {
 }
 
 type NixVolume struct {
-	Runtime           ContainerRuntime
-	Name              string
-	Driver            string
-	DriverOpts        map[string]string
-	External          bool
-	Labels            map[string]string
-	RemoveOnStop      bool
-	RequiresMountsFor []string
+	Runtime      ContainerRuntime
+	Name         string
+	Driver       string
+	DriverOpts   map[string]string
+	External     bool
+	Labels       map[string]string
+	RemoveOnStop bool
+	// Set using "compose2nix.systemd.*" labels on the volume.
+	SystemdConfig *NixContainerSystemdConfig
 }
 
 func (v *NixVolume) Path() string {
@@ -172,31 +181,141 @@ func NewNixContainerSystemdConfig() *NixContainerSystemdConfig {
 	}
 }
 
+// NixContainerSchedule configures the systemd timer that runs a scheduled
+// container.
+//
+// See: https://www.freedesktop.org/software/systemd/man/latest/systemd.timer.html
+type NixContainerSchedule struct {
+	OnCalendar         string
+	Persistent         bool
+	RandomizedDelaySec string
+	PartOf             []string
+	WantedBy           []string
+}
+
 // https://search.nixos.org/options?channel=unstable&from=0&size=50&sort=relevance&type=packages&query=oci-container
 type NixContainer struct {
-	Runtime       ContainerRuntime
-	Name          string
-	Image         string
-	Environment   map[string]string
-	EnvFiles      []string
-	Volumes       map[string]string
-	Ports         []string
-	Labels        map[string]string
-	Networks      []string
-	DependsOn     []string
-	LogDriver     string
-	ExtraOptions  []string
-	SystemdConfig *NixContainerSystemdConfig
-	User          string
-	Command       []string
-	AutoStart     bool
-	SopsSecrets   []string
+	Runtime             ContainerRuntime
+	Name                string
+	Image               string
+	Environment         map[string]string
+	EnvFiles            []string
+	Volumes             map[string]string
+	Ports               []string
+	Labels              map[string]string
+	Networks            []string
+	DependsOn           []string
+	LogDriver           string
+	ExtraOptions        []string
+	SystemdConfig       *NixContainerSystemdConfig
+	User                string
+	Command             []string
+	AutoStart           bool
+	SopsSecrets         []string
+	WaitForHealthy      bool
+	PostStart           []string // Shell commands run after the container starts.
+	PreStop             []string // Shell commands run before the container stops.
+	HealthOnFailure     string
+	HealthCheckInterval time.Duration
+	Schedule            *NixContainerSchedule
+	Pull                *NixPull
+	Login               *NixContainerLogin
+	BuildRef            string // Git ref to build from. Overrides the ref in the build context.
+	NixImage            string // Nix expression that builds the image using dockerTools. Replaces the build.
 }
 
 func (c *NixContainer) Unit() string {
 	return fmt.Sprintf("%s-%s.service", c.Runtime, c.Name)
 }
 
+// HealthCheckUnitName returns the name of the unit that acts on an unhealthy
+// container. This is only used for the Docker runtime.
+func (c *NixContainer) HealthCheckUnitName() string {
+	return fmt.Sprintf("%s-%s-healthcheck", c.Runtime, c.Name)
+}
+
+// HealthCheckCommand returns a shell script that runs the configured
+// HealthOnFailure action if the container is unhealthy.
+func (c *NixContainer) HealthCheckCommand() string {
+	name := shellQuote(c.Name)
+	var action string
+	switch c.HealthOnFailure {
+	case "kill":
+		action = fmt.Sprintf("%s kill %s", c.Runtime, name)
+	case "stop":
+		action = fmt.Sprintf("%s stop %s", c.Runtime, name)
+	case "restart":
+		action = "systemctl restart " + shellQuote(c.Unit())
+	}
+	return fmt.Sprintf(`if [ "$(%s inspect --format '{{.State.Health.Status}}' %s)" = unhealthy ]; then %s; fi`, c.Runtime, name, action)
+}
+
+// NixContainerLogin holds the credentials for the registry of the container's
+// image. Exactly one of PasswordFile or SopsSecret is set.
+type NixContainerLogin struct {
+	Registry     string
+	Username     string
+	PasswordFile string
+	SopsSecret   string
+}
+
+// Command returns a login command that reads the password from stdin.
+func (l *NixContainerLogin) Command(runtime ContainerRuntime) string {
+	return fmt.Sprintf("%s login %s --username %s --password-stdin", runtime, shellQuote(l.Registry), shellQuote(l.Username))
+}
+
+// NixPull pulls a container image in a separate systemd service.
+type NixPull struct {
+	Runtime       ContainerRuntime
+	Image         string
+	ContainerName string        // Name of the resolved Nix container.
+	Always        bool          // If false, the image is only pulled if missing.
+	Timeout       time.Duration // Includes all retries.
+	Retries       int
+	Interval      time.Duration // If set, the image is pulled periodically by a timer.
+	Login         *NixContainerLogin
+	PartOf        string
+	WantedBy      []string // Only used by the timer.
+}
+
+func (p *NixPull) UnitName() string {
+	return fmt.Sprintf("%s-pull-%s", p.Runtime, p.ContainerName)
+}
+
+func (p *NixPull) Unit() string {
+	return p.UnitName() + ".service"
+}
+
+// ImageExistsCommand returns a command that exits early if the image is
+// already present.
+func (p *NixPull) ImageExistsCommand() string {
+	return fmt.Sprintf("%s image inspect %s >/dev/null 2>&1 && exit 0", p.Runtime, shellQuote(p.Image))
+}
+
+// Script returns the lines of a shell script that pulls the image, retrying
+// with exponential backoff on failure.
+func (p *NixPull) Script() []string {
+	image := shellQuote(p.Image)
+	var lines []string
+	var delays []string
+	delay := pullRetryDelay
+	for range p.Retries {
+		delays = append(delays, fmt.Sprintf("%d", int(delay.Seconds())))
+		delay *= 2
+	}
+	if len(delays) == 0 {
+		return append(lines, fmt.Sprintf("%s pull %s", p.Runtime, image))
+	}
+	return append(lines,
+		fmt.Sprintf("for delay in %s; do", strings.Join(delays, " ")),
+		fmt.Sprintf("  %s pull %s && exit 0", p.Runtime, image),
+		`  echo "pull failed, retrying in ${delay}s" >&2`,
+		`  sleep "$delay"`,
+		"done",
+		fmt.Sprintf("%s pull %s", p.Runtime, image),
+	)
+}
+
 // https://docs.docker.com/reference/compose-file/services/#pull_policy
 // https://docs.podman.io/en/latest/markdown/podman-build.1.html#pull-policy
 type ServicePullPolicy int
@@ -207,6 +326,7 @@ const (
 	ServicePullPolicyNever
 	ServicePullPolicyMissing
 	ServicePullPolicyBuild
+	ServicePullPolicyRefresh
 	ServicePullPolicyUnset
 )
 
@@ -220,7 +340,12 @@ func NewServicePullPolicy(s string) ServicePullPolicy {
 		return ServicePullPolicyMissing
 	case "build":
 		return ServicePullPolicyBuild
+	case "refresh", "daily", "weekly":
+		return ServicePullPolicyRefresh
 	default:
+		if strings.HasPrefix(strings.TrimSpace(s), "every_") {
+			return ServicePullPolicyRefresh
+		}
 		return ServicePullPolicyUnset
 	}
 }
@@ -228,14 +353,95 @@ func NewServicePullPolicy(s string) ServicePullPolicy {
 // https://docs.docker.com/reference/compose-file/build/
 // https://docs.docker.com/reference/cli/docker/buildx/build/
 type NixBuild struct {
-	Runtime       ContainerRuntime
-	Context       string
-	PullPolicy    ServicePullPolicy
-	IsGitRepo     bool
-	Args          map[string]*string
-	Tags          []string
-	Dockerfile    string // Relative to context path.
-	ContainerName string // Name of the resolved Nix container.
+	Runtime          ContainerRuntime
+	Context          string
+	PullPolicy       ServicePullPolicy
+	IsGitRepo        bool
+	GitRef           string // Branch, tag or commit. Defaults to the remote HEAD.
+	GitSubdir        string // Relative to the repo root.
+	Args             map[string]*string
+	Tags             []string
+	Dockerfile       string // Relative to context path.
+	DockerfileInline string // Written to the Nix store. Overrides Dockerfile.
+	ContainerName    string // Name of the resolved Nix container.
+
+	Target             string
+	Network            string
+	Platforms          []string
+	CacheFrom          []string
+	CacheTo            []string
+	Labels             map[string]string
+	ShmSize            int64 // In bytes.
+	ExtraHosts         []string
+	NoCache            bool
+	Pull               bool
+	AdditionalContexts []string // name=context
+	Secrets            []NixBuildSecret
+	SSH                []string // default or id=path
+	RestartTriggers    []string // Nix expressions.
+	// Set using "compose2nix.systemd.build.*" labels on the service, or
+	// "compose2nix.systemd.*" labels on the build.
+	SystemdConfig *NixContainerSystemdConfig
+}
+
+// BuildTriggerMode controls how build services detect changes to their build
+// context.
+type BuildTriggerMode int
+
+const (
+	BuildTriggerModeNone BuildTriggerMode = iota
+	BuildTriggerModeHash                  // Content hash computed at generation time.
+	BuildTriggerModePath                  // Context referenced as a Nix path.
+)
+
+func NewBuildTriggerMode(s string) (BuildTriggerMode, error) {
+	switch s {
+	case "":
+		return BuildTriggerModeNone, nil
+	case "hash":
+		return BuildTriggerModeHash, nil
+	case "path":
+		return BuildTriggerModePath, nil
+	default:
+		return BuildTriggerModeNone, fmt.Errorf("invalid build trigger mode %q", s)
+	}
+}
+
+// NixBuildSecret is a secret that is exposed to the build. Exactly one of Src
+// or SopsSecret is set.
+type NixBuildSecret struct {
+	ID         string
+	Src        string
+	SopsSecret string
+}
+
+// EnvVar returns the name of the environment variable that holds the path to
+// the sops secret in the build service.
+func (s NixBuildSecret) EnvVar() string {
+	return "BUILD_SECRET_" + strings.ToUpper(nonAlphaNumRegexp.ReplaceAllString(s.ID, "_"))
+}
+
+func (s NixBuildSecret) Flag() string {
+	if s.SopsSecret != "" {
+		return fmt.Sprintf(`--secret "id=%s,src=$%s"`, s.ID, s.EnvVar())
+	}
+	return "--secret " + shellQuote(fmt.Sprintf("id=%s,src=%s", s.ID, s.Src))
+}
+
+// DockerfileInlineLines returns the lines of the inline Dockerfile.
+func (b *NixBuild) DockerfileInlineLines() []string {
+	return strings.Split(strings.TrimRight(b.DockerfileInline, "\n"), "\n")
+}
+
+// SopsSecrets returns the build's secrets that are sourced from sops.
+func (b *NixBuild) SopsSecrets() []NixBuildSecret {
+	var secrets []NixBuildSecret
+	for _, s := range b.Secrets {
+		if s.SopsSecret != "" {
+			secrets = append(secrets, s)
+		}
+	}
+	return secrets
 }
 
 func (b *NixBuild) UnitName() string {
@@ -246,28 +452,114 @@ func (b *NixBuild) Unit() string {
 	return b.UnitName() + ".service"
 }
 
+// StateDirectory returns the cache directory for a Git repo, relative to
+// /var/lib.
+func (b *NixBuild) StateDirectory() string {
+	return "compose2nix/" + b.UnitName()
+}
+
+// GitCheckoutCommands returns shell commands that fetch the Git repo into the
+// cache directory and change into the build context.
+func (b *NixBuild) GitCheckoutCommands() []string {
+	ref := ""
+	if b.GitRef != "" {
+		ref = " " + shellQuote(b.GitRef)
+	}
+	cmds := []string{
+		"cd /var/lib/" + b.StateDirectory(),
+		"[ -d .git ] || git init --quiet",
+		fmt.Sprintf("git fetch --force --depth=1 %s%s", shellQuote(b.Context), ref),
+		"git checkout --force --detach FETCH_HEAD",
+		"git clean -ffdx",
+	}
+	if b.GitSubdir != "" {
+		cmds = append(cmds, "cd "+shellQuote(b.GitSubdir))
+	}
+	return cmds
+}
+
 func (b *NixBuild) Command() string {
 	cmd := fmt.Sprintf("%s build", b.Runtime)
 
 	for _, tag := range b.Tags {
 		cmd += fmt.Sprintf(" -t %s", tag)
 	}
-	for name, arg := range b.Args {
+	// For builds, the pull policy applies to the base image(s). "build.pull"
+	// takes precedence as it always pulls.
+	switch {
+	case b.Pull || b.PullPolicy == ServicePullPolicyAlways:
+		if b.Runtime == ContainerRuntimeDocker {
+			cmd += " --pull"
+		} else {
+			cmd += " --pull=always"
+		}
+	case b.PullPolicy == ServicePullPolicyRefresh:
+		if b.Runtime == ContainerRuntimeDocker {
+			cmd += " --pull"
+		} else {
+			cmd += " --pull=newer"
+		}
+	case b.PullPolicy == ServicePullPolicyNever:
+		// Docker always pulls missing base images.
+		if b.Runtime == ContainerRuntimePodman {
+			cmd += " --pull=never"
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(b.Args)) {
+		arg := b.Args[name]
 		if arg != nil {
 			cmd += fmt.Sprintf(" --build-arg %s=%s", name, *arg)
 		} else {
 			cmd += fmt.Sprintf(" --build-arg %s", name)
 		}
 	}
-	if b.Dockerfile != "" && b.Dockerfile != "Dockerfile" {
+	if b.DockerfileInline != "" {
+		// The path to the Dockerfile in the Nix store is set in the unit's
+		// environment. See: build.nix.tmpl.
+		cmd += ` -f "$DOCKERFILE"`
+	} else if b.Dockerfile != "" && b.Dockerfile != "Dockerfile" {
 		cmd += fmt.Sprintf(" -f %s", b.Dockerfile)
 	}
-
-	if b.IsGitRepo {
-		cmd += " " + b.Context
-	} else {
-		cmd += " ."
+	if b.Target != "" {
+		cmd += " --target=" + b.Target
+	}
+	if b.Network != "" {
+		cmd += " --network=" + b.Network
+	}
+	if len(b.Platforms) > 0 {
+		cmd += " --platform=" + strings.Join(b.Platforms, ",")
+	}
+	for _, cache := range b.CacheFrom {
+		cmd += " --cache-from=" + cache
+	}
+	for _, cache := range b.CacheTo {
+		cmd += " --cache-to=" + cache
+	}
+	for _, name := range slices.Sorted(maps.Keys(b.Labels)) {
+		cmd += " --label=" + shellQuote(fmt.Sprintf("%s=%s", name, b.Labels[name]))
+	}
+	if b.ShmSize != 0 {
+		cmd += fmt.Sprintf(" --shm-size=%db", b.ShmSize)
 	}
+	for _, host := range b.ExtraHosts {
+		cmd += " --add-host=" + host
+	}
+	if b.NoCache {
+		cmd += " --no-cache"
+	}
+	for _, cx := range b.AdditionalContexts {
+		cmd += " --build-context=" + cx
+	}
+	for _, secret := range b.Secrets {
+		cmd += " " + secret.Flag()
+	}
+	for _, ssh := range b.SSH {
+		cmd += " --ssh " + ssh
+	}
+
+	// Git repos are checked out first, so we always build from the current
+	// directory.
+	cmd += " ."
 
 	return cmd
 }
@@ -296,6 +588,14 @@ func (c *NixContainerConfig) HasSopsSecrets() bool {
 		if len(container.SopsSecrets) > 0 {
 			return true
 		}
+		if container.Login != nil && container.Login.SopsSecret != "" {
+			return true
+		}
+	}
+	for _, build := range c.Builds {
+		if len(build.SopsSecrets()) > 0 {
+			return true
+		}
 	}
 	return false
 }
@@ -8,10 +8,13 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -168,6 +171,102 @@ func TestUnusedResources(t *testing.T) {
 	runSubtestsWithGenerator(t, g)
 }
 
+func TestSystemdRepeatedKeys(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:   []string{composePath},
+		Project:  NewProject("test"),
+		RootPath: "/some/path",
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestSystemdRepeatedKeys_Conflict(t *testing.T) {
+	c := NewNixContainerSystemdConfig()
+	labels := map[string]string{
+		"compose2nix.systemd.service.ExecStartPre":   "/bin/foo",
+		"compose2nix.systemd.service.ExecStartPre.0": "/bin/bar",
+	}
+	if _, err := c.parseSystemdLabels(labels, true); err == nil {
+		t.Errorf("expected error for key set with and without an index, got nil")
+	}
+}
+
+func TestSystemdLabels_Invalid(t *testing.T) {
+	labels := map[string]string{
+		"compose2nix.systemd.sevice.Restart":          "always",
+		"compose2nix.systemd.service.ExecStartPre.x":  "/bin/foo",
+		"compose2nix.systemd.service.RuntimeMaxSec":   "10",
+		"compose2nix.systemd.build.TimeoutSec":        "600",
+		"compose2nix.settings.autoStart":              "false",
+		"com.example.compose2nix.systemd.unit.Ignore": "true",
+	}
+	testCases := []struct {
+		allowBuildLabels bool
+		want             []string
+	}{
+		{true, []string{"compose2nix.systemd.service.ExecStartPre.x", "compose2nix.systemd.sevice.Restart"}},
+		{false, []string{"compose2nix.systemd.build.TimeoutSec", "compose2nix.systemd.service.ExecStartPre.x", "compose2nix.systemd.sevice.Restart"}},
+	}
+	for _, tc := range testCases {
+		c := NewNixContainerSystemdConfig()
+		warnings, err := c.parseSystemdLabels(labels, tc.allowBuildLabels)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(warnings) != len(tc.want) {
+			t.Fatalf("parseSystemdLabels(allowBuildLabels=%v) = %q, want warnings for %q", tc.allowBuildLabels, warnings, tc.want)
+		}
+		for i, label := range tc.want {
+			if !strings.Contains(warnings[i], fmt.Sprintf("invalid systemd label %q", label)) {
+				t.Errorf("parseSystemdLabels(allowBuildLabels=%v): warning %q does not mention %q", tc.allowBuildLabels, warnings[i], label)
+			}
+		}
+		if got := c.Service.Options["RuntimeMaxSec"]; got != 10 {
+			t.Errorf("RuntimeMaxSec = %v, want 10", got)
+		}
+	}
+}
+
+func TestSystemdLabelEscaping(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:   []string{composePath},
+		Project:  NewProject("test"),
+		RootPath: "/some/path",
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestSystemdSchema(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:   []string{composePath},
+		Project:  NewProject("test"),
+		RootPath: "/some/path",
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestSystemdSchema_WarningsAsErrors(t *testing.T) {
+	ctx := context.Background()
+	composePath := path.Join("testdata", "TestSystemdSchema.compose.yml")
+	g := &Generator{
+		Runtime:          ContainerRuntimePodman,
+		Inputs:           []string{composePath},
+		Project:          NewProject("test"),
+		RootPath:         "/some/path",
+		WarningsAsErrors: true,
+	}
+	_, err := g.Run(ctx)
+	if err == nil {
+		t.Fatalf("expected error for unknown systemd option, got nil")
+	}
+	if want := `did you mean "RestartSec"?`; !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error to contain %q, got: %v", want, err)
+	}
+}
+
 func TestSystemdMount(t *testing.T) {
 	composePath, envFilePath := getPaths(t, true)
 	g := &Generator{
@@ -482,6 +581,147 @@ func TestBuildSpec_BuildEnabled(t *testing.T) {
 	runSubtestsWithGenerator(t, g)
 }
 
+func TestBuildSpec_Full(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:   []string{composePath},
+		Project:  NewProject("test"),
+		RootPath: "/some/path",
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestBuildSecrets(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	sopsConfig := NewSopsConfig(path.Join("testdata", "TestBuildSecrets.secrets.yaml"))
+	if err := sopsConfig.LoadSecrets(); err != nil {
+		t.Fatalf("Failed to load sops config: %v", err)
+	}
+	g := &Generator{
+		Inputs:     []string{composePath},
+		Project:    NewProject("test"),
+		RootPath:   "/some/path",
+		SopsConfig: sopsConfig,
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestDockerfileInline(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:   []string{composePath},
+		Project:  NewProject("test"),
+		RootPath: "/some/path",
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestResourceSystemdLabels(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:       []string{composePath},
+		Project:      NewProject("test"),
+		RootPath:     "/some/path",
+		IncludeBuild: true,
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestNixImage(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:       []string{composePath},
+		Project:      NewProject("test"),
+		RootPath:     "/some/path",
+		IncludeBuild: true,
+		PullUnits:    true,
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestBuildServiceConfig(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	buildServiceConfig, _, err := ParseSystemdOptions("TimeoutSec=900, CPUQuota=50%,Nice=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Generator{
+		Inputs:             []string{composePath},
+		Project:            NewProject("test"),
+		RootPath:           "/some/path",
+		IncludeBuild:       true,
+		BuildServiceConfig: buildServiceConfig,
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestBuildServiceConfig_Reserved(t *testing.T) {
+	ctx := context.Background()
+	composePath := path.Join("testdata", "TestBuildServiceConfig.compose.yml")
+	buildServiceConfig, _, err := ParseSystemdOptions("Type=simple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Generator{
+		Runtime:            ContainerRuntimePodman,
+		Inputs:             []string{composePath},
+		Project:            NewProject("test"),
+		RootPath:           "/some/path",
+		BuildServiceConfig: buildServiceConfig,
+	}
+	if _, err := g.Run(ctx); err == nil {
+		t.Errorf("expected error for reserved build option, got nil")
+	}
+}
+
+func TestBuildTriggers_Hash(t *testing.T) {
+	composePath := path.Join("testdata", "TestBuildTriggers.compose.yml")
+	g := &Generator{
+		Inputs:        []string{composePath},
+		Project:       NewProject("test"),
+		IncludeBuild:  true,
+		BuildTriggers: BuildTriggerModeHash,
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestBuildTriggers_Path(t *testing.T) {
+	composePath := path.Join("testdata", "TestBuildTriggers.compose.yml")
+	g := &Generator{
+		Inputs:        []string{composePath},
+		Project:       NewProject("test"),
+		IncludeBuild:  true,
+		BuildTriggers: BuildTriggerModePath,
+		OutputDir:     "testdata",
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestGitBuildContext(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:       []string{composePath},
+		Project:      NewProject("test"),
+		RootPath:     "/some/path",
+		IncludeBuild: true,
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestGitBuildContext_AbbreviatedSHA(t *testing.T) {
+	ctx := context.Background()
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:           []string{composePath},
+		Project:          NewProject("test"),
+		RootPath:         "/some/path",
+		WarningsAsErrors: true,
+	}
+	if _, err := g.Run(ctx); err == nil {
+		t.Errorf("expected error for abbreviated commit SHA, got nil")
+	}
+}
+
 func TestSopsIntegration(t *testing.T) {
 	composePath, _ := getPaths(t, false)
 	sopsPath := path.Join("testdata", "sops-example", "secrets", "pinnacle.yaml")
@@ -562,3 +802,234 @@ func TestUlimits(t *testing.T) {
 	}
 	runSubtestsWithGenerator(t, g)
 }
+
+func TestResources(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:  []string{composePath},
+		Project: NewProject("test"),
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestResources_CPUConflict(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:  []string{composePath},
+		Project: NewProject("test"),
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestResources_WarningsAsErrors(t *testing.T) {
+	ctx := context.Background()
+	composePath := path.Join("testdata", "TestResources.compose.yml")
+	g := &Generator{
+		Runtime:          ContainerRuntimePodman,
+		Inputs:           []string{composePath},
+		Project:          NewProject("test"),
+		RootPath:         ".",
+		WarningsAsErrors: true,
+	}
+	if _, err := g.Run(ctx); err == nil {
+		t.Errorf("expected error for unsupported resource options, got nil")
+	}
+}
+
+func TestBlkioConfig(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:  []string{composePath},
+		Project: NewProject("test"),
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestNamespaces(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:  []string{composePath},
+		Project: NewProject("test"),
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestServiceFlags(t *testing.T) {
+	for _, key := range []string{
+		"read_only",
+		"working_dir",
+		"tty",
+		"stdin_open",
+		"domainname",
+		"platform",
+		"annotations",
+		"storage_opt",
+		"label_file",
+		"pull_policy",
+	} {
+		t.Run(key, func(t *testing.T) {
+			composePath := path.Join("testdata", fmt.Sprintf("TestServiceFlags.%s.compose.yml", key))
+			g := &Generator{
+				Inputs:  []string{composePath},
+				Project: NewProject("test"),
+			}
+			runSubtestsWithGenerator(t, g)
+		})
+	}
+}
+
+func TestUnsupportedKeys(t *testing.T) {
+	ctx := context.Background()
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Runtime:  ContainerRuntimeDocker,
+		Inputs:   []string{composePath},
+		Project:  NewProject("test"),
+		RootPath: ".",
+	}
+	if _, err := g.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	g.WarningsAsErrors = true
+	if _, err := g.Run(ctx); err == nil {
+		t.Errorf("expected error for unsupported keys, got nil")
+	}
+}
+
+func TestUnsupportedKeys_List(t *testing.T) {
+	ctx := context.Background()
+	composePath := path.Join("testdata", "TestUnsupportedKeys.compose.yml")
+	composeProject, err := loader.LoadWithContext(ctx, types.ConfigDetails{
+		ConfigFiles: types.ToConfigFiles([]string{composePath}),
+		WorkingDir:  ".",
+	}, func(o *loader.Options) { o.SetProjectName("test", true) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, err := composeProject.GetService("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := unsupportedKeys(reflect.ValueOf(service), supportedServiceKeys, "")
+	want := []string{"deploy.mode", "dns_search", "stop_signal"}
+	if diff := cmp.Diff(want, got, sortSlicesOpt); diff != "" {
+		t.Errorf("unsupported keys diff (-want,+got):\n%s", diff)
+	}
+}
+
+func TestLinks(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:  []string{composePath},
+		Project: NewProject("test"),
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestVolumesFrom(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:  []string{composePath},
+		Project: NewProject("test"),
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestServiceHooks(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:  []string{composePath},
+		Project: NewProject("test"),
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestWaitForHealthy(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:                []string{composePath},
+		Project:               NewProject("test"),
+		WaitForHealthy:        true,
+		WaitForHealthyTimeout: time.Minute,
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestSchedule(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:    []string{composePath},
+		Project:   NewProject("test"),
+		AutoStart: true,
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestPullUnits(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:      []string{composePath},
+		Project:     NewProject("test"),
+		PullUnits:   true,
+		PullTimeout: 10 * time.Minute,
+		PullRetries: 2,
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestPullPolicy(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:    []string{composePath},
+		Project:   NewProject("test"),
+		RootPath:  "/some/path",
+		AutoStart: true,
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestRegistryAuth(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	sopsConfig := NewSopsConfig(path.Join("testdata", "TestRegistryAuth.secrets.yaml"))
+	if err := sopsConfig.LoadSecrets(); err != nil {
+		t.Fatalf("Failed to load sops config: %v", err)
+	}
+	registryAuth, err := LoadRegistryAuth(path.Join("testdata", "TestRegistryAuth.registry_auth.yml"), sopsConfig)
+	if err != nil {
+		t.Fatalf("Failed to load registry auth: %v", err)
+	}
+	g := &Generator{
+		Inputs:       []string{composePath},
+		Project:      NewProject("test"),
+		RootPath:     "/some/path",
+		SopsConfig:   sopsConfig,
+		RegistryAuth: registryAuth,
+		PullUnits:    true,
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestImageRewrite(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	rules, err := ParseImageRewriteRules("docker.io=mirror.internal/dockerhub,ghcr.io=mirror.internal/ghcr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Generator{
+		Inputs:            []string{composePath},
+		Project:           NewProject("test"),
+		RootPath:          "/some/path",
+		ImageRewriteRules: rules,
+	}
+	runSubtestsWithGenerator(t, g)
+}
+
+func TestHealthOnFailure(t *testing.T) {
+	composePath, _ := getPaths(t, false)
+	g := &Generator{
+		Inputs:  []string{composePath},
+		Project: NewProject("test"),
+	}
+	runSubtestsWithGenerator(t, g)
+}
@@ -173,7 +173,7 @@
     startLimitBurst = 3;
     unitConfig = {
       Description = "Container service-b generated by compose2nix.";
-      AllowIsolate = lib.mkOverride 90 "no";
+      AllowIsolate = lib.mkOverride 90 false;
       StartLimitIntervalSec = lib.mkOverride 90 "infinity";
     };
     after = [
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Images without an explicit registry are pulled from Docker Hub.
+const dockerHubRegistry = "docker.io"
+
+// RegistryCredentials are used to login to a private registry. The password
+// is read from a file or from a sops secret.
+type RegistryCredentials struct {
+	Username     string `yaml:"username"`
+	PasswordFile string `yaml:"passwordFile"`
+	SopsSecret   string `yaml:"sopsSecret"`
+}
+
+// RegistryAuth maps registry hosts (e.g., "ghcr.io") to credentials.
+type RegistryAuth map[string]RegistryCredentials
+
+// LoadRegistryAuth reads registry credentials from a YAML file.
+//
+// Example:
+//
+//	ghcr.io:
+//	  username: me
+//	  passwordFile: /run/secrets/ghcr-token
+//	registry.example.com:
+//	  username: deploy
+//	  sopsSecret: registry/password
+func LoadRegistryAuth(filePath string, sopsConfig *SopsConfig) (RegistryAuth, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry auth file %q: %w", filePath, err)
+	}
+	var auth RegistryAuth
+	if err := yaml.Unmarshal(content, &auth); err != nil {
+		return nil, fmt.Errorf("failed to parse registry auth file %q: %w", filePath, err)
+	}
+	normalized := make(RegistryAuth, len(auth))
+	for registry, creds := range auth {
+		if creds.Username == "" {
+			return nil, fmt.Errorf("registry %q: username must be set", registry)
+		}
+		if (creds.PasswordFile == "") == (creds.SopsSecret == "") {
+			return nil, fmt.Errorf("registry %q: exactly one of passwordFile or sopsSecret must be set", registry)
+		}
+		if creds.SopsSecret != "" {
+			if sopsConfig == nil {
+				return nil, fmt.Errorf("registry %q: sopsSecret set, but no sops config specified", registry)
+			}
+			if !sopsConfig.HasSecret(creds.SopsSecret) {
+				return nil, fmt.Errorf("sops secret %q not found in sops config file %q", creds.SopsSecret, sopsConfig.FilePath)
+			}
+		}
+		normalized[normalizeRegistry(registry)] = creds
+	}
+	return normalized, nil
+}
+
+// Login returns the login config for the given image, or nil if the image's
+// registry has no credentials.
+func (a RegistryAuth) Login(image string) *NixContainerLogin {
+	registry := imageRegistry(image)
+	creds, ok := a[registry]
+	if !ok {
+		return nil
+	}
+	return &NixContainerLogin{
+		Registry:     registry,
+		Username:     creds.Username,
+		PasswordFile: creds.PasswordFile,
+		SopsSecret:   creds.SopsSecret,
+	}
+}
+
+func normalizeRegistry(registry string) string {
+	registry = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://"), "/")
+	switch registry {
+	case "index.docker.io", "registry-1.docker.io":
+		return dockerHubRegistry
+	}
+	return registry
+}
+
+// imageRegistry returns the registry host of an image reference.
+//
+// Like Docker, the first path component is only treated as a registry if it
+// looks like a host (i.e., contains a "." or ":", or is "localhost").
+func imageRegistry(image string) string {
+	first, _, found := strings.Cut(image, "/")
+	if !found || (!strings.ContainsAny(first, ".:") && first != "localhost") {
+		return dockerHubRegistry
+	}
+	return normalizeRegistry(first)
+}
+
+// ImageRewriteRule rewrites image references that start with From (e.g., a
+// registry mirror).
+type ImageRewriteRule struct {
+	From string
+	To   string
+}
+
+// ParseImageRewriteRules parses comma-separated rules of the form "from=to".
+func ParseImageRewriteRules(s string) ([]ImageRewriteRule, error) {
+	var rules []ImageRewriteRule
+	for _, rule := range strings.Split(s, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		from, to, found := strings.Cut(rule, "=")
+		from, to = strings.TrimSuffix(strings.TrimSpace(from), "/"), strings.TrimSuffix(strings.TrimSpace(to), "/")
+		if !found || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid image rewrite rule %q: must be of the form \"from=to\"", rule)
+		}
+		rules = append(rules, ImageRewriteRule{From: from, To: to})
+	}
+	return rules, nil
+}
+
+// normalizeImage returns the fully-qualified form of a Docker Hub image
+// (e.g., "postgres:16" -> "docker.io/library/postgres:16"). Images on other
+// registries are returned as-is.
+func normalizeImage(image string) string {
+	if imageRegistry(image) != dockerHubRegistry {
+		return image
+	}
+	name := image
+	if first, rest, found := strings.Cut(image, "/"); found && normalizeRegistry(first) == dockerHubRegistry {
+		name = rest
+	}
+	if !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	return dockerHubRegistry + "/" + name
+}
+
+// rewriteImage applies the first matching rule to the image. Rules only match
+// on path, tag or digest boundaries. If normalize is set, Docker Hub images are
+// normalized before matching. If no rule matches, the image is returned as-is.
+func rewriteImage(image string, rules []ImageRewriteRule, normalize bool) string {
+	candidate := image
+	if normalize {
+		candidate = normalizeImage(image)
+	}
+	for _, rule := range rules {
+		rest, ok := strings.CutPrefix(candidate, rule.From)
+		if ok && (rest == "" || strings.ContainsRune("/:@", rune(rest[0]))) {
+			return rule.To + rest
+		}
+	}
+	return image
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestImageRegistry(t *testing.T) {
+	testCases := map[string]string{
+		"postgres:16":                 "docker.io",
+		"someuser/tool":               "docker.io",
+		"docker.io/library/postgres":  "docker.io",
+		"index.docker.io/someuser/x":  "docker.io",
+		"ghcr.io/me/app:latest":       "ghcr.io",
+		"localhost/app":               "localhost",
+		"registry.example.com:5000/x": "registry.example.com:5000",
+	}
+	for image, want := range testCases {
+		if got := imageRegistry(image); got != want {
+			t.Errorf("imageRegistry(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestRewriteImage(t *testing.T) {
+	rules, err := ParseImageRewriteRules("docker.io/=mirror.internal/dockerhub/, ghcr.io=mirror.internal/ghcr,quay.io/app=mirror.internal/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		image     string
+		normalize bool
+		want      string
+	}{
+		{"postgres:16", true, "mirror.internal/dockerhub/library/postgres:16"},
+		{"postgres:16", false, "postgres:16"},
+		{"someuser/tool", true, "mirror.internal/dockerhub/someuser/tool"},
+		{"docker.io/someuser/tool", false, "mirror.internal/dockerhub/someuser/tool"},
+		{"ghcr.io/me/app:latest", true, "mirror.internal/ghcr/me/app:latest"},
+		{"quay.io/app:1.0", true, "mirror.internal/app:1.0"},
+		{"quay.io/application:1.0", true, "quay.io/application:1.0"},
+		{"ghcr.io.example.com/app", true, "ghcr.io.example.com/app"},
+	}
+	for _, tc := range testCases {
+		if got := rewriteImage(tc.image, rules, tc.normalize); got != tc.want {
+			t.Errorf("rewriteImage(%q, normalize=%v) = %q, want %q", tc.image, tc.normalize, got, tc.want)
+		}
+	}
+}
+
+func TestParseImageRewriteRules_Invalid(t *testing.T) {
+	for _, s := range []string{"docker.io", "=mirror", "docker.io="} {
+		if _, err := ParseImageRewriteRules(s); err == nil {
+			t.Errorf("ParseImageRewriteRules(%q): expected error", s)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"maps"
 	"regexp"
 	"slices"
 	"strconv"
@@ -14,6 +15,25 @@ import (
 const (
 	// https://www.freedesktop.org/software/systemd/man/latest/systemd-system.conf.html#DefaultTimeoutStartSec=
 	defaultSystemdStopTimeout = 90 * time.Second
+
+	// Maximum time to wait for a Docker container to become healthy.
+	defaultWaitForHealthyTimeout = 5 * time.Minute
+
+	// Maximum time to wait for a Docker container to start running before
+	// its post_start hooks are run.
+	postStartWaitTimeout = 1 * time.Minute
+
+	// https://docs.docker.com/reference/dockerfile/#healthcheck
+	defaultHealthCheckInterval = 30 * time.Second
+
+	// Maximum time for a pull unit to pull an image, including retries.
+	defaultPullTimeout = 5 * time.Minute
+
+	// Delay before the first pull retry. This is doubled after each attempt.
+	pullRetryDelay = 10 * time.Second
+
+	// Maximum time for a build unit to build an image.
+	defaultBuildTimeout = 5 * time.Minute
 )
 
 var (
@@ -25,7 +45,21 @@ var (
 	// Examples:
 	// compose2nix.systemd.service.RuntimeMaxSec=100
 	// compose2nix.systemd.unit.StartLimitBurst=10
-	systemdLabelRegexp = regexp.MustCompile(fmt.Sprintf(`%s\.systemd\.(service|unit)\.(\w+)`, composeLabelPrefix))
+	//
+	// Repeated keys are set using an index suffix, e.g.:
+	// compose2nix.systemd.service.ExecStartPre.0=/bin/foo
+	// compose2nix.systemd.service.ExecStartPre.1=/bin/bar
+	systemdLabelRegexp = regexp.MustCompile(fmt.Sprintf(`^%s\.systemd\.(service|unit)\.(\w+)(?:\.(\d+))?$`, composeLabelPrefix))
+
+	// Example:
+	// compose2nix.systemd.build.TimeoutSec=900
+	systemdBuildLabelRegexp = regexp.MustCompile(fmt.Sprintf(`^%s\.systemd\.build\.(\w+)(?:\.(\d+))?$`, composeLabelPrefix))
+	systemdKeyRegexp        = regexp.MustCompile(`^\w+$`)
+
+	// Service options that are always set by compose2nix on build, network
+	// and volume units.
+	reservedBuildServiceOptions    = []string{"Type", "RemainAfterExit", "StateDirectory"}
+	reservedResourceServiceOptions = []string{"Type", "RemainAfterExit", "ExecStop"}
 )
 
 // https://www.freedesktop.org/software/systemd/man/latest/systemd.syntax.html
@@ -53,7 +87,78 @@ func parseSystemdValue(v string) any {
 	return v
 }
 
-// TODO(aksiksi): Add support for repeated keys.
+// systemdLabelValues collects raw label values by key. Indexed labels (e.g.,
+// "ExecStartPre.0") are collected into lists ordered by index.
+type systemdLabelValues struct {
+	values  map[string]string
+	indexed map[string]map[int]string
+}
+
+func (s *systemdLabelValues) add(key, index, value string) error {
+	if index == "" {
+		if s.values == nil {
+			s.values = map[string]string{}
+		}
+		s.values[key] = value
+		return nil
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return fmt.Errorf("invalid index %q for systemd option %q: %w", index, key, err)
+	}
+	if s.indexed == nil {
+		s.indexed = map[string]map[int]string{}
+	}
+	if s.indexed[key] == nil {
+		s.indexed[key] = map[int]string{}
+	}
+	s.indexed[key][i] = value
+	return nil
+}
+
+// resolve validates the collected values against the schema and returns the
+// normalized values by key, along with any warnings.
+func (s *systemdLabelValues) resolve(schema systemdSchema) (map[string]any, []string, error) {
+	values := map[string]any{}
+	var warnings []string
+	for key, raw := range s.values {
+		if _, ok := s.indexed[key]; ok {
+			return nil, nil, fmt.Errorf("systemd option %q is set both with and without an index", key)
+		}
+		v, warning := normalizeSystemdOption(schema, key, []string{raw}, false)
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+		values[key] = v
+	}
+	for key, indexed := range s.indexed {
+		var raw []string
+		for _, i := range slices.Sorted(maps.Keys(indexed)) {
+			raw = append(raw, indexed[i])
+		}
+		v, warning := normalizeSystemdOption(schema, key, raw, true)
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+		values[key] = v
+	}
+	slices.Sort(warnings)
+	return values, warnings, nil
+}
+
+// appendSystemdValues appends a single value or a list of values to s.
+func appendSystemdValues(s []string, value any) []string {
+	if values, ok := value.([]any); ok {
+		for _, v := range values {
+			s = append(s, fmt.Sprint(v))
+		}
+		return s
+	}
+	return append(s, fmt.Sprint(value))
+}
+
+// ServiceConfig holds options for the [Service] section. List values (i.e.,
+// []any) are used for repeated keys.
 type ServiceConfig struct {
 	// Map for generic options.
 	Options map[string]any
@@ -66,7 +171,63 @@ func (s *ServiceConfig) Set(key string, value any) {
 	s.Options[key] = value
 }
 
-// TODO(aksiksi): Add support for repeated keys.
+// ParseSystemdOptions parses comma-separated systemd options of the form
+// "key=value" (e.g., "TimeoutSec=900,Nice=10"). Options are validated against
+// the [Service] schema.
+func ParseSystemdOptions(s string) (ServiceConfig, []string, error) {
+	var config ServiceConfig
+	var values systemdLabelValues
+	for _, option := range strings.Split(s, ",") {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			continue
+		}
+		key, value, found := strings.Cut(option, "=")
+		key = strings.TrimSpace(key)
+		if !found || !systemdKeyRegexp.MatchString(key) {
+			return config, nil, fmt.Errorf("invalid systemd option %q: must be of the form \"key=value\"", option)
+		}
+		if err := values.add(key, "", value); err != nil {
+			return config, nil, err
+		}
+	}
+	resolved, warnings, err := values.resolve(systemdServiceSchema)
+	if err != nil {
+		return config, nil, err
+	}
+	for k, v := range resolved {
+		config.Set(k, v)
+	}
+	return config, warnings, nil
+}
+
+// ParseSystemdBuildLabels sets options for the service's build unit from
+// "compose2nix.systemd.build.*" labels. Any warnings from validating the
+// options are returned.
+func (s *ServiceConfig) ParseSystemdBuildLabels(service *types.ServiceConfig) ([]string, error) {
+	var values systemdLabelValues
+	for label, value := range service.Labels {
+		m := systemdBuildLabelRegexp.FindStringSubmatch(label)
+		if len(m) == 0 {
+			continue
+		}
+		if err := values.add(m[1], m[2], value); err != nil {
+			return nil, err
+		}
+	}
+	resolved, warnings, err := values.resolve(systemdServiceSchema)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range resolved {
+		s.Set(k, v)
+	}
+	return warnings, nil
+}
+
+// UnitConfig holds options for the [Unit] section. Dependencies that NixOS
+// exposes as lists are tracked separately. List values (i.e., []any) are used
+// for other repeated keys.
 type UnitConfig struct {
 	After             []string
 	Requires          []string
@@ -84,17 +245,17 @@ func (u *UnitConfig) Set(key string, value any) {
 	}
 	switch key {
 	case "After":
-		u.After = append(u.After, value.(string))
+		u.After = appendSystemdValues(u.After, value)
 	case "Requires":
-		u.Requires = append(u.Requires, value.(string))
+		u.Requires = appendSystemdValues(u.Requires, value)
 	case "PartOf":
-		u.PartOf = append(u.PartOf, value.(string))
+		u.PartOf = appendSystemdValues(u.PartOf, value)
 	case "UpheldBy":
-		u.UpheldBy = append(u.UpheldBy, value.(string))
+		u.UpheldBy = appendSystemdValues(u.UpheldBy, value)
 	case "WantedBy":
-		u.WantedBy = append(u.WantedBy, value.(string))
+		u.WantedBy = appendSystemdValues(u.WantedBy, value)
 	case "RequiresMountsFor":
-		u.RequiresMountsFor = append(u.RequiresMountsFor, value.(string))
+		u.RequiresMountsFor = appendSystemdValues(u.RequiresMountsFor, value)
 	default:
 		u.Options[key] = value
 	}
@@ -177,28 +338,94 @@ func (c *NixContainerSystemdConfig) ParseRestartPolicy(service *types.ServiceCon
 	return nil
 }
 
-func (c *NixContainerSystemdConfig) ParseSystemdLabels(service *types.ServiceConfig) error {
-	for label, value := range service.Labels {
-		if !strings.HasPrefix(label, composeLabelPrefix) {
+// ParseSystemdLabels sets service and unit options from the service's
+// "compose2nix.systemd.*" labels. Any warnings from validating the options are
+// returned.
+func (c *NixContainerSystemdConfig) ParseSystemdLabels(service *types.ServiceConfig) ([]string, error) {
+	return c.parseSystemdLabels(service.Labels, true)
+}
+
+// parseSystemdLabels sets service and unit options from "compose2nix.systemd.*"
+// labels. This is used for containers as well as network, volume and build
+// units. "compose2nix.systemd.build.*" labels are only valid on services, where
+// they are parsed separately.
+func (c *NixContainerSystemdConfig) parseSystemdLabels(labels map[string]string, allowBuildLabels bool) ([]string, error) {
+	var service, unit systemdLabelValues
+	var invalid []string
+	for label, value := range labels {
+		if !strings.HasPrefix(label, composeLabelPrefix+".systemd.") {
 			continue
 		}
 		m := systemdLabelRegexp.FindStringSubmatch(label)
 		if len(m) == 0 {
+			if !allowBuildLabels || !systemdBuildLabelRegexp.MatchString(label) {
+				invalid = append(invalid, label)
+			}
 			continue
 		}
-		typ, key := m[1], m[2]
+		typ, key, index := m[1], m[2], m[3]
+		var err error
 		switch typ {
 		case "service":
-			c.Service.Set(key, parseSystemdValue(value))
+			err = service.add(key, index, value)
 		case "unit":
-			c.Unit.Set(key, parseSystemdValue(value))
+			err = unit.add(key, index, value)
 		default:
-			return fmt.Errorf(`invalid systemd type %q - must be "service" or "unit"`, typ)
+			return nil, fmt.Errorf(`invalid systemd type %q - must be "service" or "unit"`, typ)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	serviceValues, serviceWarnings, err := service.resolve(systemdServiceSchema)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range serviceValues {
+		c.Service.Set(k, v)
+	}
+	unitValues, unitWarnings, err := unit.resolve(systemdUnitSchema)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range unitValues {
+		c.Unit.Set(k, v)
+	}
+	slices.Sort(invalid)
+	warnings := make([]string, 0, len(invalid)+len(serviceWarnings)+len(unitWarnings))
+	for _, label := range invalid {
+		warnings = append(warnings, fmt.Sprintf("invalid systemd label %q will be ignored (must be %s.systemd.service.<option> or %s.systemd.unit.<option>, with an optional numeric index)", label, composeLabelPrefix, composeLabelPrefix))
+	}
+	warnings = append(warnings, serviceWarnings...)
+	return append(warnings, unitWarnings...), nil
+}
+
+// checkReservedOptions returns an error if any of the given service options,
+// which are always set by compose2nix, are overridden.
+func (c *NixContainerSystemdConfig) checkReservedOptions(keys []string) error {
+	for _, key := range keys {
+		if _, ok := c.Service.Options[key]; ok {
+			return fmt.Errorf("systemd option %q is set by compose2nix and cannot be overridden", key)
 		}
 	}
 	return nil
 }
 
+// stripSystemdLabels returns a copy of the labels without "compose2nix.systemd.*"
+// labels. These only configure the generated systemd units.
+func stripSystemdLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	stripped := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if !strings.HasPrefix(k, composeLabelPrefix+".systemd.") {
+			stripped[k] = v
+		}
+	}
+	return stripped
+}
+
 func (c *NixContainerSystemdConfig) Sort() {
 	slices.Sort(c.Unit.After)
 	slices.Sort(c.Unit.Requires)
@@ -0,0 +1,538 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type systemdValueType int
+
+const (
+	systemdValueString systemdValueType = iota
+	systemdValueBool
+	systemdValueInt
+	systemdValueTimespan // Seconds or a time span (e.g., "5min 20s").
+	systemdValueSize     // Bytes with an optional unit (e.g., "4G"), or a percentage.
+	systemdValueList     // Can be repeated.
+	systemdValueEnum
+)
+
+type systemdDirective struct {
+	Type   systemdValueType
+	Values []string // Allowed values for enums.
+}
+
+// systemdSchema describes the known directives of a systemd unit section.
+type systemdSchema struct {
+	Section    string
+	Directives map[string]systemdDirective
+}
+
+var (
+	// https://www.freedesktop.org/software/systemd/man/latest/systemd.time.html
+	systemdTimespanRegexp = regexp.MustCompile(`^(\d+(\.\d+)?\s*(us|usec|ms|msec|s|sec|second|seconds|m|min|minute|minutes|h|hr|hour|hours|d|day|days|w|week|weeks|M|month|months|y|year|years)?\s*)+$`)
+	// https://www.freedesktop.org/software/systemd/man/latest/systemd.resource-control.html
+	systemdSizeRegexp = regexp.MustCompile(`^\d+(\.\d+)?([KMGTPE]|%)?$`)
+
+	systemdBoolTrue  = []string{"true", "yes", "on", "1"}
+	systemdBoolFalse = []string{"false", "no", "off", "0"}
+
+	// https://www.freedesktop.org/software/systemd/man/latest/systemd.unit.html#FailureAction=
+	systemdUnitActions = []string{
+		"none", "reboot", "reboot-force", "reboot-immediate", "poweroff", "poweroff-force", "poweroff-immediate",
+		"exit", "exit-force", "soft-reboot", "soft-reboot-force", "kexec", "kexec-force", "halt", "halt-force",
+		"halt-immediate",
+	}
+)
+
+// https://www.freedesktop.org/software/systemd/man/latest/systemd.unit.html
+var systemdUnitSchema = systemdSchema{
+	Section: "Unit",
+	Directives: map[string]systemdDirective{
+		"Description":                 {Type: systemdValueString},
+		"Documentation":               {Type: systemdValueList},
+		"Wants":                       {Type: systemdValueList},
+		"Requires":                    {Type: systemdValueList},
+		"Requisite":                   {Type: systemdValueList},
+		"BindsTo":                     {Type: systemdValueList},
+		"PartOf":                      {Type: systemdValueList},
+		"Upholds":                     {Type: systemdValueList},
+		"Conflicts":                   {Type: systemdValueList},
+		"Before":                      {Type: systemdValueList},
+		"After":                       {Type: systemdValueList},
+		"OnFailure":                   {Type: systemdValueList},
+		"OnSuccess":                   {Type: systemdValueList},
+		"PropagatesReloadTo":          {Type: systemdValueList},
+		"ReloadPropagatedFrom":        {Type: systemdValueList},
+		"PropagatesStopTo":            {Type: systemdValueList},
+		"StopPropagatedFrom":          {Type: systemdValueList},
+		"JoinsNamespaceOf":            {Type: systemdValueList},
+		"RequiresMountsFor":           {Type: systemdValueList},
+		"WantsMountsFor":              {Type: systemdValueList},
+		"OnSuccessJobMode":            {Type: systemdValueEnum, Values: []string{"fail", "replace", "replace-irreversibly", "isolate", "flush", "ignore-dependencies", "ignore-requirements"}},
+		"OnFailureJobMode":            {Type: systemdValueEnum, Values: []string{"fail", "replace", "replace-irreversibly", "isolate", "flush", "ignore-dependencies", "ignore-requirements"}},
+		"IgnoreOnIsolate":             {Type: systemdValueBool},
+		"StopWhenUnneeded":            {Type: systemdValueBool},
+		"RefuseManualStart":           {Type: systemdValueBool},
+		"RefuseManualStop":            {Type: systemdValueBool},
+		"AllowIsolate":                {Type: systemdValueBool},
+		"DefaultDependencies":         {Type: systemdValueBool},
+		"SurviveFinalKillSignal":      {Type: systemdValueBool},
+		"CollectMode":                 {Type: systemdValueEnum, Values: []string{"inactive", "inactive-or-failed"}},
+		"FailureAction":               {Type: systemdValueEnum, Values: systemdUnitActions},
+		"SuccessAction":               {Type: systemdValueEnum, Values: systemdUnitActions},
+		"FailureActionExitStatus":     {Type: systemdValueInt},
+		"SuccessActionExitStatus":     {Type: systemdValueInt},
+		"JobTimeoutSec":               {Type: systemdValueTimespan},
+		"JobRunningTimeoutSec":        {Type: systemdValueTimespan},
+		"JobTimeoutAction":            {Type: systemdValueEnum, Values: systemdUnitActions},
+		"JobTimeoutRebootArgument":    {Type: systemdValueString},
+		"StartLimitIntervalSec":       {Type: systemdValueTimespan},
+		"StartLimitBurst":             {Type: systemdValueInt},
+		"StartLimitAction":            {Type: systemdValueEnum, Values: systemdUnitActions},
+		"RebootArgument":              {Type: systemdValueString},
+		"SourcePath":                  {Type: systemdValueString},
+		"ConditionPathExists":         {Type: systemdValueList},
+		"ConditionPathExistsGlob":     {Type: systemdValueList},
+		"ConditionPathIsDirectory":    {Type: systemdValueList},
+		"ConditionPathIsSymbolicLink": {Type: systemdValueList},
+		"ConditionPathIsMountPoint":   {Type: systemdValueList},
+		"ConditionPathIsReadWrite":    {Type: systemdValueList},
+		"ConditionDirectoryNotEmpty":  {Type: systemdValueList},
+		"ConditionFileNotEmpty":       {Type: systemdValueList},
+		"ConditionFileIsExecutable":   {Type: systemdValueList},
+		"ConditionHost":               {Type: systemdValueList},
+		"ConditionVirtualization":     {Type: systemdValueList},
+		"ConditionKernelCommandLine":  {Type: systemdValueList},
+		"ConditionACPower":            {Type: systemdValueBool},
+		"AssertPathExists":            {Type: systemdValueList},
+		"AssertPathIsDirectory":       {Type: systemdValueList},
+		"AssertPathIsMountPoint":      {Type: systemdValueList},
+		"AssertFileNotEmpty":          {Type: systemdValueList},
+		// [Install] section. NixOS sets these through the unit.
+		"WantedBy":   {Type: systemdValueList},
+		"RequiredBy": {Type: systemdValueList},
+		"UpheldBy":   {Type: systemdValueList},
+	},
+}
+
+// [Service] options, including the exec, kill and resource control options
+// that are shared with other unit types.
+//
+// https://www.freedesktop.org/software/systemd/man/latest/systemd.service.html
+// https://www.freedesktop.org/software/systemd/man/latest/systemd.exec.html
+// https://www.freedesktop.org/software/systemd/man/latest/systemd.kill.html
+// https://www.freedesktop.org/software/systemd/man/latest/systemd.resource-control.html
+var systemdServiceSchema = systemdSchema{
+	Section: "Service",
+	Directives: map[string]systemdDirective{
+		// systemd.service
+		"Type":                        {Type: systemdValueEnum, Values: []string{"simple", "exec", "forking", "oneshot", "dbus", "notify", "notify-reload", "idle"}},
+		"ExitType":                    {Type: systemdValueEnum, Values: []string{"main", "cgroup"}},
+		"RemainAfterExit":             {Type: systemdValueBool},
+		"GuessMainPID":                {Type: systemdValueBool},
+		"PIDFile":                     {Type: systemdValueString},
+		"BusName":                     {Type: systemdValueString},
+		"ExecStart":                   {Type: systemdValueList},
+		"ExecStartPre":                {Type: systemdValueList},
+		"ExecStartPost":               {Type: systemdValueList},
+		"ExecCondition":               {Type: systemdValueList},
+		"ExecReload":                  {Type: systemdValueList},
+		"ExecStop":                    {Type: systemdValueList},
+		"ExecStopPost":                {Type: systemdValueList},
+		"RestartSec":                  {Type: systemdValueTimespan},
+		"RestartSteps":                {Type: systemdValueInt},
+		"RestartMaxDelaySec":          {Type: systemdValueTimespan},
+		"TimeoutStartSec":             {Type: systemdValueTimespan},
+		"TimeoutStopSec":              {Type: systemdValueTimespan},
+		"TimeoutAbortSec":             {Type: systemdValueTimespan},
+		"TimeoutSec":                  {Type: systemdValueTimespan},
+		"TimeoutStartFailureMode":     {Type: systemdValueEnum, Values: []string{"terminate", "abort", "kill"}},
+		"TimeoutStopFailureMode":      {Type: systemdValueEnum, Values: []string{"terminate", "abort", "kill"}},
+		"RuntimeMaxSec":               {Type: systemdValueTimespan},
+		"RuntimeRandomizedExtraSec":   {Type: systemdValueTimespan},
+		"WatchdogSec":                 {Type: systemdValueTimespan},
+		"Restart":                     {Type: systemdValueEnum, Values: []string{"no", "always", "on-success", "on-failure", "on-abnormal", "on-abort", "on-watchdog"}},
+		"RestartMode":                 {Type: systemdValueEnum, Values: []string{"normal", "direct", "debug"}},
+		"SuccessExitStatus":           {Type: systemdValueList},
+		"RestartPreventExitStatus":    {Type: systemdValueList},
+		"RestartForceExitStatus":      {Type: systemdValueList},
+		"RootDirectoryStartOnly":      {Type: systemdValueBool},
+		"NonBlocking":                 {Type: systemdValueBool},
+		"NotifyAccess":                {Type: systemdValueEnum, Values: []string{"none", "main", "exec", "all"}},
+		"OOMPolicy":                   {Type: systemdValueEnum, Values: []string{"continue", "stop", "kill"}},
+		"FileDescriptorStoreMax":      {Type: systemdValueInt},
+		"FileDescriptorStorePreserve": {Type: systemdValueEnum, Values: []string{"no", "yes", "restart"}},
+		"ReloadSignal":                {Type: systemdValueString},
+		"Sockets":                     {Type: systemdValueList},
+		"OpenFile":                    {Type: systemdValueList},
+
+		// systemd.exec
+		"User":                       {Type: systemdValueString},
+		"Group":                      {Type: systemdValueString},
+		"DynamicUser":                {Type: systemdValueBool},
+		"SupplementaryGroups":        {Type: systemdValueList},
+		"WorkingDirectory":           {Type: systemdValueString},
+		"RootDirectory":              {Type: systemdValueString},
+		"Environment":                {Type: systemdValueList},
+		"EnvironmentFile":            {Type: systemdValueList},
+		"PassEnvironment":            {Type: systemdValueList},
+		"UnsetEnvironment":           {Type: systemdValueList},
+		"UMask":                      {Type: systemdValueString},
+		"Nice":                       {Type: systemdValueInt},
+		"IOSchedulingClass":          {Type: systemdValueEnum, Values: []string{"realtime", "best-effort", "idle", "none", "0", "1", "2", "3"}},
+		"IOSchedulingPriority":       {Type: systemdValueInt},
+		"CPUSchedulingPolicy":        {Type: systemdValueEnum, Values: []string{"other", "batch", "idle", "fifo", "rr"}},
+		"CPUSchedulingPriority":      {Type: systemdValueInt},
+		"CPUAffinity":                {Type: systemdValueList},
+		"OOMScoreAdjust":             {Type: systemdValueInt},
+		"LimitCORE":                  {Type: systemdValueString},
+		"LimitMEMLOCK":               {Type: systemdValueString},
+		"LimitNOFILE":                {Type: systemdValueString},
+		"LimitNPROC":                 {Type: systemdValueString},
+		"StandardInput":              {Type: systemdValueString},
+		"StandardOutput":             {Type: systemdValueString},
+		"StandardError":              {Type: systemdValueString},
+		"SyslogIdentifier":           {Type: systemdValueString},
+		"LogLevelMax":                {Type: systemdValueString},
+		"ProtectSystem":              {Type: systemdValueEnum, Values: []string{"true", "false", "yes", "no", "full", "strict"}},
+		"ProtectHome":                {Type: systemdValueEnum, Values: []string{"true", "false", "yes", "no", "read-only", "tmpfs"}},
+		"PrivateTmp":                 {Type: systemdValueBool},
+		"PrivateDevices":             {Type: systemdValueBool},
+		"PrivateNetwork":             {Type: systemdValueBool},
+		"NoNewPrivileges":            {Type: systemdValueBool},
+		"ProtectKernelTunables":      {Type: systemdValueBool},
+		"ProtectKernelModules":       {Type: systemdValueBool},
+		"ProtectControlGroups":       {Type: systemdValueBool},
+		"ReadWritePaths":             {Type: systemdValueList},
+		"ReadOnlyPaths":              {Type: systemdValueList},
+		"InaccessiblePaths":          {Type: systemdValueList},
+		"StateDirectory":             {Type: systemdValueList},
+		"RuntimeDirectory":           {Type: systemdValueList},
+		"CacheDirectory":             {Type: systemdValueList},
+		"LogsDirectory":              {Type: systemdValueList},
+		"ConfigurationDirectory":     {Type: systemdValueList},
+		"CapabilityBoundingSet":      {Type: systemdValueList},
+		"AmbientCapabilities":        {Type: systemdValueList},
+		"SystemCallFilter":           {Type: systemdValueList},
+		"LoadCredential":             {Type: systemdValueList},
+		"SetCredential":              {Type: systemdValueList},
+		"BindPaths":                  {Type: systemdValueList},
+		"BindReadOnlyPaths":          {Type: systemdValueList},
+		"RuntimeDirectoryPreserve":   {Type: systemdValueEnum, Values: []string{"no", "yes", "restart"}},
+		"RootImage":                  {Type: systemdValueString},
+		"RootImageOptions":           {Type: systemdValueList},
+		"MountAPIVFS":                {Type: systemdValueBool},
+		"BindLogSockets":             {Type: systemdValueBool},
+		"ProtectProc":                {Type: systemdValueEnum, Values: []string{"noaccess", "invisible", "ptraceable", "default"}},
+		"ProcSubset":                 {Type: systemdValueEnum, Values: []string{"all", "pid"}},
+		"ExecSearchPath":             {Type: systemdValueList},
+		"MountImages":                {Type: systemdValueList},
+		"ExtensionImages":            {Type: systemdValueList},
+		"ExtensionDirectories":       {Type: systemdValueList},
+		"PAMName":                    {Type: systemdValueString},
+		"SetLoginEnvironment":        {Type: systemdValueBool},
+		"SecureBits":                 {Type: systemdValueList},
+		"LimitCPU":                   {Type: systemdValueString},
+		"LimitFSIZE":                 {Type: systemdValueString},
+		"LimitDATA":                  {Type: systemdValueString},
+		"LimitSTACK":                 {Type: systemdValueString},
+		"LimitRSS":                   {Type: systemdValueString},
+		"LimitAS":                    {Type: systemdValueString},
+		"LimitLOCKS":                 {Type: systemdValueString},
+		"LimitSIGPENDING":            {Type: systemdValueString},
+		"LimitMSGQUEUE":              {Type: systemdValueString},
+		"LimitNICE":                  {Type: systemdValueString},
+		"LimitRTPRIO":                {Type: systemdValueString},
+		"LimitRTTIME":                {Type: systemdValueString},
+		"CoredumpFilter":             {Type: systemdValueList},
+		"KeyringMode":                {Type: systemdValueEnum, Values: []string{"inherit", "private", "shared"}},
+		"TimerSlackNSec":             {Type: systemdValueTimespan},
+		"Personality":                {Type: systemdValueString},
+		"IgnoreSIGPIPE":              {Type: systemdValueBool},
+		"CPUSchedulingResetOnFork":   {Type: systemdValueBool},
+		"NUMAPolicy":                 {Type: systemdValueEnum, Values: []string{"default", "preferred", "bind", "interleave", "local"}},
+		"NUMAMask":                   {Type: systemdValueString},
+		"ExecPaths":                  {Type: systemdValueList},
+		"NoExecPaths":                {Type: systemdValueList},
+		"TemporaryFileSystem":        {Type: systemdValueList},
+		"StateDirectoryMode":         {Type: systemdValueString},
+		"RuntimeDirectoryMode":       {Type: systemdValueString},
+		"CacheDirectoryMode":         {Type: systemdValueString},
+		"LogsDirectoryMode":          {Type: systemdValueString},
+		"ConfigurationDirectoryMode": {Type: systemdValueString},
+		"StateDirectoryAccounting":   {Type: systemdValueBool},
+		"PrivateIPC":                 {Type: systemdValueBool},
+		"PrivatePIDs":                {Type: systemdValueBool},
+		"PrivateUsers":               {Type: systemdValueEnum, Values: []string{"true", "false", "yes", "no", "self", "identity", "full"}},
+		"ProtectHostname":            {Type: systemdValueEnum, Values: []string{"true", "false", "yes", "no", "private"}},
+		"ProtectClock":               {Type: systemdValueBool},
+		"ProtectKernelLogs":          {Type: systemdValueBool},
+		"NetworkNamespacePath":       {Type: systemdValueString},
+		"IPCNamespacePath":           {Type: systemdValueString},
+		"PrivateMounts":              {Type: systemdValueBool},
+		"MountFlags":                 {Type: systemdValueEnum, Values: []string{"shared", "slave", "private"}},
+		"RestrictNamespaces":         {Type: systemdValueList},
+		"RestrictAddressFamilies":    {Type: systemdValueList},
+		"RestrictFileSystems":        {Type: systemdValueList},
+		"RestrictRealtime":           {Type: systemdValueBool},
+		"RestrictSUIDSGID":           {Type: systemdValueBool},
+		"RemoveIPC":                  {Type: systemdValueBool},
+		"LockPersonality":            {Type: systemdValueBool},
+		"MemoryDenyWriteExecute":     {Type: systemdValueBool},
+		"MemoryKSM":                  {Type: systemdValueBool},
+		"SystemCallArchitectures":    {Type: systemdValueList},
+		"SystemCallErrorNumber":      {Type: systemdValueString},
+		"SystemCallLog":              {Type: systemdValueList},
+		"SELinuxContext":             {Type: systemdValueString},
+		"AppArmorProfile":            {Type: systemdValueString},
+		"SmackProcessLabel":          {Type: systemdValueString},
+		"LoadCredentialEncrypted":    {Type: systemdValueList},
+		"SetCredentialEncrypted":     {Type: systemdValueList},
+		"ImportCredential":           {Type: systemdValueList},
+		"StandardInputText":          {Type: systemdValueList},
+		"StandardInputData":          {Type: systemdValueList},
+		"LogRateLimitIntervalSec":    {Type: systemdValueTimespan},
+		"LogRateLimitBurst":          {Type: systemdValueInt},
+		"LogExtraFields":             {Type: systemdValueList},
+		"LogFilterPatterns":          {Type: systemdValueList},
+		"LogNamespace":               {Type: systemdValueString},
+		"SyslogFacility":             {Type: systemdValueString},
+		"SyslogLevel":                {Type: systemdValueString},
+		"SyslogLevelPrefix":          {Type: systemdValueBool},
+		"TTYPath":                    {Type: systemdValueString},
+		"TTYReset":                   {Type: systemdValueBool},
+		"TTYVHangup":                 {Type: systemdValueBool},
+		"TTYVTDisallocate":           {Type: systemdValueBool},
+		"UtmpIdentifier":             {Type: systemdValueString},
+		"UtmpMode":                   {Type: systemdValueEnum, Values: []string{"init", "login", "user"}},
+
+		// systemd.kill
+		"KillMode":          {Type: systemdValueEnum, Values: []string{"control-group", "mixed", "process", "none"}},
+		"KillSignal":        {Type: systemdValueString},
+		"RestartKillSignal": {Type: systemdValueString},
+		"FinalKillSignal":   {Type: systemdValueString},
+		"SendSIGKILL":       {Type: systemdValueBool},
+		"SendSIGHUP":        {Type: systemdValueBool},
+		"WatchdogSignal":    {Type: systemdValueString},
+
+		// systemd.resource-control
+		"Slice":                               {Type: systemdValueString},
+		"Delegate":                            {Type: systemdValueString},
+		"CPUAccounting":                       {Type: systemdValueBool},
+		"CPUWeight":                           {Type: systemdValueString},
+		"CPUQuota":                            {Type: systemdValueSize},
+		"AllowedCPUs":                         {Type: systemdValueString},
+		"MemoryAccounting":                    {Type: systemdValueBool},
+		"MemoryMin":                           {Type: systemdValueSize},
+		"MemoryLow":                           {Type: systemdValueSize},
+		"MemoryHigh":                          {Type: systemdValueSize},
+		"MemoryMax":                           {Type: systemdValueSize},
+		"MemorySwapMax":                       {Type: systemdValueSize},
+		"TasksAccounting":                     {Type: systemdValueBool},
+		"TasksMax":                            {Type: systemdValueSize},
+		"IOAccounting":                        {Type: systemdValueBool},
+		"IOWeight":                            {Type: systemdValueInt},
+		"IPAccounting":                        {Type: systemdValueBool},
+		"IPAddressAllow":                      {Type: systemdValueList},
+		"IPAddressDeny":                       {Type: systemdValueList},
+		"DeviceAllow":                         {Type: systemdValueList},
+		"DevicePolicy":                        {Type: systemdValueEnum, Values: []string{"auto", "closed", "strict"}},
+		"ManagedOOMSwap":                      {Type: systemdValueEnum, Values: []string{"auto", "kill"}},
+		"ManagedOOMMemoryPressure":            {Type: systemdValueEnum, Values: []string{"auto", "kill"}},
+		"ManagedOOMMemoryPressureLimit":       {Type: systemdValueSize},
+		"ManagedOOMMemoryPressureDurationSec": {Type: systemdValueTimespan},
+		"ManagedOOMPreference":                {Type: systemdValueEnum, Values: []string{"none", "avoid", "omit"}},
+		"DelegateSubgroup":                    {Type: systemdValueString},
+		"DisableControllers":                  {Type: systemdValueList},
+		"StartupCPUWeight":                    {Type: systemdValueString},
+		"CPUQuotaPeriodSec":                   {Type: systemdValueTimespan},
+		"StartupAllowedCPUs":                  {Type: systemdValueString},
+		"AllowedMemoryNodes":                  {Type: systemdValueString},
+		"StartupAllowedMemoryNodes":           {Type: systemdValueString},
+		"DefaultMemoryMin":                    {Type: systemdValueSize},
+		"DefaultMemoryLow":                    {Type: systemdValueSize},
+		"StartupMemoryLow":                    {Type: systemdValueSize},
+		"StartupMemoryHigh":                   {Type: systemdValueSize},
+		"StartupMemoryMax":                    {Type: systemdValueSize},
+		"StartupMemorySwapMax":                {Type: systemdValueSize},
+		"MemoryZSwapMax":                      {Type: systemdValueSize},
+		"StartupMemoryZSwapMax":               {Type: systemdValueSize},
+		"MemoryZSwapWriteback":                {Type: systemdValueBool},
+		"StartupIOWeight":                     {Type: systemdValueInt},
+		"IODeviceWeight":                      {Type: systemdValueList},
+		"IOReadBandwidthMax":                  {Type: systemdValueList},
+		"IOWriteBandwidthMax":                 {Type: systemdValueList},
+		"IOReadIOPSMax":                       {Type: systemdValueList},
+		"IOWriteIOPSMax":                      {Type: systemdValueList},
+		"IODeviceLatencyTargetSec":            {Type: systemdValueList},
+		"IPIngressFilterPath":                 {Type: systemdValueList},
+		"IPEgressFilterPath":                  {Type: systemdValueList},
+		"BPFProgram":                          {Type: systemdValueList},
+		"SocketBindAllow":                     {Type: systemdValueList},
+		"SocketBindDeny":                      {Type: systemdValueList},
+		"RestrictNetworkInterfaces":           {Type: systemdValueList},
+		"NFTSet":                              {Type: systemdValueList},
+		"MemoryPressureWatch":                 {Type: systemdValueEnum, Values: []string{"auto", "on", "off", "skip"}},
+		"MemoryPressureThresholdSec":          {Type: systemdValueTimespan},
+		"CoredumpReceive":                     {Type: systemdValueBool},
+	},
+}
+
+// unquoteSystemdValue trims whitespace and a single pair of matching quotes
+// around the value.
+func unquoteSystemdValue(v string) string {
+	v = strings.TrimSpace(v)
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		v = v[1 : len(v)-1]
+	}
+	return v
+}
+
+// normalize validates a raw value against the directive's type and returns the
+// value to use in Nix.
+//
+// Quotes are only removed for scalar values. Lists are passed to systemd as-is,
+// as systemd uses quotes to group words in lists (e.g., Environment=).
+func (d systemdDirective) normalize(raw string) (any, error) {
+	if d.Type == systemdValueList {
+		return strings.TrimSpace(raw), nil
+	}
+	v := unquoteSystemdValue(raw)
+	switch d.Type {
+	case systemdValueBool:
+		switch {
+		case slices.Contains(systemdBoolTrue, strings.ToLower(v)):
+			return true, nil
+		case slices.Contains(systemdBoolFalse, strings.ToLower(v)):
+			return false, nil
+		}
+		return nil, fmt.Errorf("must be a boolean")
+	case systemdValueInt:
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return i, nil
+	case systemdValueTimespan:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i, nil
+		}
+		if v == "infinity" || systemdTimespanRegexp.MatchString(v) {
+			return v, nil
+		}
+		return nil, fmt.Errorf(`must be a time span (e.g., "90", "5min 20s" or "infinity")`)
+	case systemdValueSize:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i, nil
+		}
+		if v == "infinity" || systemdSizeRegexp.MatchString(v) {
+			return v, nil
+		}
+		return nil, fmt.Errorf(`must be a size (e.g., "512M", "50%%" or "infinity")`)
+	case systemdValueEnum:
+		if slices.Contains(d.Values, v) {
+			return v, nil
+		}
+		err := fmt.Errorf("must be one of: %s", strings.Join(d.Values, ", "))
+		if match := closestMatch(v, d.Values); match != "" {
+			err = fmt.Errorf("%w (did you mean %q?)", err, match)
+		}
+		return nil, err
+	default:
+		return v, nil
+	}
+}
+
+// normalizeSystemdOption validates the value(s) of a directive set using
+// labels and returns the normalized value. Indexed values are returned as a
+// list.
+//
+// Unknown directives and invalid values are returned as parsed by
+// parseSystemdValue. Invalid values always generate a warning. As the schema
+// cannot cover every directive of every systemd version, unknown directives
+// only generate a warning if they look like a typo of a known directive.
+func normalizeSystemdOption(schema systemdSchema, key string, raw []string, indexed bool) (any, string) {
+	fallback := func() any {
+		if !indexed {
+			return parseSystemdValue(raw[0])
+		}
+		list := make([]any, len(raw))
+		for i, v := range raw {
+			list[i] = parseSystemdValue(v)
+		}
+		return list
+	}
+
+	d, ok := schema.Directives[key]
+	if !ok {
+		warning := fmt.Sprintf("unknown systemd [%s] option %q", schema.Section, key)
+		for _, other := range []systemdSchema{systemdUnitSchema, systemdServiceSchema} {
+			if _, ok := other.Directives[key]; ok && other.Section != schema.Section {
+				return fallback(), fmt.Sprintf("%s (%q is a [%s] option)", warning, key, other.Section)
+			}
+		}
+		if match := closestMatch(key, slices.Collect(maps.Keys(schema.Directives))); match != "" {
+			return fallback(), fmt.Sprintf("%s (did you mean %q?)", warning, match)
+		}
+		return fallback(), ""
+	}
+
+	if indexed {
+		if d.Type != systemdValueList {
+			return fallback(), fmt.Sprintf("systemd option %q cannot be repeated", key)
+		}
+		list := make([]any, len(raw))
+		for i, v := range raw {
+			list[i] = strings.TrimSpace(v)
+		}
+		return list, ""
+	}
+
+	v, err := d.normalize(raw[0])
+	if err != nil {
+		return fallback(), fmt.Sprintf("invalid value %q for systemd option %q: %v", raw[0], key, err)
+	}
+	return v, ""
+}
+
+// closestMatch returns the candidate closest to s, or an empty string if no
+// candidate is close enough to be a likely typo.
+func closestMatch(s string, candidates []string) string {
+	const maxDistance = 2
+	best, bestDistance := "", maxDistance+1
+	for _, c := range slices.Sorted(slices.Values(candidates)) {
+		if strings.EqualFold(s, c) {
+			return c
+		}
+		if d := levenshtein(strings.ToLower(s), strings.ToLower(c)); d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSystemdOption(t *testing.T) {
+	testCases := []struct {
+		schema  systemdSchema
+		key     string
+		raw     []string
+		indexed bool
+		want    any
+		warning string
+	}{
+		{systemdServiceSchema, "RestartSec", []string{"5"}, false, 5, ""},
+		{systemdServiceSchema, "RestartSec", []string{"1min 30s"}, false, "1min 30s", ""},
+		{systemdServiceSchema, "TimeoutStopSec", []string{"infinity"}, false, "infinity", ""},
+		{systemdServiceSchema, "TimeoutStopSec", []string{"forever"}, false, "forever", "must be a time span"},
+		{systemdServiceSchema, "MemoryMax", []string{"4G"}, false, "4G", ""},
+		{systemdServiceSchema, "CPUQuota", []string{"50%"}, false, "50%", ""},
+		{systemdServiceSchema, "MemoryMax", []string{"4 gigs"}, false, "4 gigs", "must be a size"},
+		{systemdServiceSchema, "PrivateTmp", []string{"yes"}, false, true, ""},
+		{systemdServiceSchema, "PrivateTmp", []string{"0"}, false, false, ""},
+		{systemdServiceSchema, "PrivateTmp", []string{"maybe"}, false, "maybe", "must be a boolean"},
+		{systemdServiceSchema, "Nice", []string{"10"}, false, 10, ""},
+		{systemdServiceSchema, "Nice", []string{"low"}, false, "low", "must be an integer"},
+		{systemdServiceSchema, "Restart", []string{"'no'"}, false, "no", ""},
+		{systemdServiceSchema, "Restart", []string{"alwyas"}, false, "alwyas", `did you mean "always"?`},
+		{systemdServiceSchema, "ExecStartPre", []string{`/bin/sh -c "echo 'hi'"`}, false, `/bin/sh -c "echo 'hi'"`, ""},
+		{systemdServiceSchema, "ExecStartPre", []string{"/bin/foo", "/bin/bar"}, true, []any{"/bin/foo", "/bin/bar"}, ""},
+		{systemdServiceSchema, "Nice", []string{"1", "2"}, true, []any{1, 2}, "cannot be repeated"},
+		{systemdServiceSchema, "RestartSecs", []string{"5"}, false, 5, `did you mean "RestartSec"?`},
+		{systemdServiceSchema, "restartsec", []string{"5"}, false, 5, `did you mean "RestartSec"?`},
+		{systemdServiceSchema, "After", []string{"foo.service"}, false, "foo.service", "is a [Unit] option"},
+		{systemdServiceSchema, "SomethingElse", []string{"x"}, false, "x", ""},
+		{systemdServiceSchema, "ProtectKernelLogs", []string{"yes"}, false, true, ""},
+		{systemdServiceSchema, "ProtectKernelLog", []string{"yes"}, false, "yes", `did you mean "ProtectKernelLogs"?`},
+		{systemdServiceSchema, "ProtectProc", []string{"invisible"}, false, "invisible", ""},
+		{systemdServiceSchema, "PrivateUsers", []string{"self"}, false, "self", ""},
+		{systemdServiceSchema, "RestrictAddressFamilies", []string{"AF_UNIX AF_INET AF_INET6"}, false, "AF_UNIX AF_INET AF_INET6", ""},
+		{systemdServiceSchema, "IOReadBandwidthMax", []string{"/dev/sda 10M"}, false, "/dev/sda 10M", ""},
+		{systemdServiceSchema, "LimitSTACK", []string{"8M"}, false, "8M", ""},
+		{systemdUnitSchema, "Wants", []string{"a.service", "b.service"}, true, []any{"a.service", "b.service"}, ""},
+		{systemdUnitSchema, "StartLimitBurst", []string{"3"}, false, 3, ""},
+	}
+	for _, tc := range testCases {
+		got, warning := normalizeSystemdOption(tc.schema, tc.key, tc.raw, tc.indexed)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("normalizeSystemdOption(%q, %q) = %#v, want %#v", tc.key, tc.raw, got, tc.want)
+		}
+		if tc.warning == "" && warning != "" {
+			t.Errorf("normalizeSystemdOption(%q, %q): unexpected warning: %s", tc.key, tc.raw, warning)
+		} else if !strings.Contains(warning, tc.warning) {
+			t.Errorf("normalizeSystemdOption(%q, %q): warning %q does not contain %q", tc.key, tc.raw, warning, tc.warning)
+		}
+	}
+}
+
+func TestClosestMatch(t *testing.T) {
+	candidates := []string{"RestartSec", "RuntimeMaxSec", "TimeoutSec"}
+	testCases := map[string]string{
+		"RestartSecs":  "RestartSec",
+		"restartsec":   "RestartSec",
+		"TimeoutSecs":  "TimeoutSec",
+		"RuntimeMax":   "",
+		"Environment":  "",
+		"RuntimeMaxSe": "RuntimeMaxSec",
+	}
+	for s, want := range testCases {
+		if got := closestMatch(s, candidates); got != want {
+			t.Errorf("closestMatch(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
@@ -42,7 +42,14 @@ func derefInt(v *int) int {
 func toNixValue(v any) any {
 	switch v := v.(type) {
 	case string:
-		return fmt.Sprintf("%q", escapeNixString(v))
+		// We purposefully do not use %q to avoid escaping the string twice.
+		return fmt.Sprintf(`"%s"`, escapeNixString(v))
+	case []any:
+		elems := make([]string, len(v))
+		for i, e := range v {
+			elems[i] = fmt.Sprint(toNixValue(e))
+		}
+		return fmt.Sprintf("[ %s ]", strings.Join(elems, " "))
 	default:
 		return v
 	}
@@ -102,4 +109,5 @@ var funcMap template.FuncMap = template.FuncMap{
 	"escapeNixString":         escapeNixString,
 	"escapeIndentedNixString": escapeIndentedNixString,
 	"escapeSystemdValue":      escapeSystemdValue,
+	"shellQuote":              shellQuote,
 }
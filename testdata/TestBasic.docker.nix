@@ -273,9 +273,6 @@
       "traefik.http.routers.traefik.service" = "api@internal";
       "traefik.http.routers.traefik.tls.certresolver" = "htpc";
     };
-    dependsOn = [
-      "sabnzbd"
-    ];
     log-driver = "journald";
     extraOptions = [
       "--network=container:sabnzbd"
@@ -289,6 +286,9 @@
       Description = "Container traefik generated by compose2nix.";
       AllowIsolate = lib.mkOverride 90 true;
     };
+    after = [
+      "docker-sabnzbd.service"
+    ];
     partOf = [
       "docker-compose-myproject-root.target"
     ];
@@ -0,0 +1,131 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-test-blkio-bps" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--device-read-bps=/dev/sda:12582912"
+      "--device-write-bps=/dev/sdb:1048576"
+      "--network-alias=test-blkio-bps"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-test-blkio-bps" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-blkio-bps generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-test-blkio-iops" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--device-read-iops=/dev/sda:120"
+      "--device-write-iops=/dev/sdb:30"
+      "--network-alias=test-blkio-iops"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-test-blkio-iops" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-blkio-iops generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-test-blkio-weight" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--blkio-weight-device=/dev/sda:400"
+      "--blkio-weight=300"
+      "--network-alias=test-blkio-weight"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-test-blkio-weight" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-blkio-weight generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-test-device-cgroup-rules" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--device-cgroup-rule=a 7:* rmw"
+      "--device-cgroup-rule=c 1:3 mr"
+      "--network-alias=test-device-cgroup-rules"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-test-device-cgroup-rules" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-device-cgroup-rules generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
@@ -0,0 +1,140 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.podman = {
+    enable = true;
+    autoPrune.enable = true;
+    dockerCompat = true;
+  };
+
+  # Enable container name DNS for all Podman networks.
+  networking.firewall.interfaces = let
+    matchAll = if !config.networking.nftables.enable then "podman+" else "podman*";
+  in {
+    "${matchAll}".allowedUDPPorts = [ 53 ];
+  };
+
+  virtualisation.oci-containers.backend = "podman";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-test-blkio-bps" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--device-read-bps=/dev/sda:12582912"
+      "--device-write-bps=/dev/sdb:1048576"
+      "--network-alias=test-blkio-bps"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-test-blkio-bps" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-blkio-bps generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-test-blkio-iops" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--device-read-iops=/dev/sda:120"
+      "--device-write-iops=/dev/sdb:30"
+      "--network-alias=test-blkio-iops"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-test-blkio-iops" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-blkio-iops generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-test-blkio-weight" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--blkio-weight-device=/dev/sda:400"
+      "--blkio-weight=300"
+      "--network-alias=test-blkio-weight"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-test-blkio-weight" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-blkio-weight generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-test-device-cgroup-rules" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--device-cgroup-rule=a 7:* rmw"
+      "--device-cgroup-rule=c 1:3 mr"
+      "--network-alias=test-device-cgroup-rules"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-test-device-cgroup-rules" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-device-cgroup-rules generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."podman-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_default";
+    };
+    script = ''
+      podman network inspect test_default || podman network create test_default
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."podman-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
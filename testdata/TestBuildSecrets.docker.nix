@@ -0,0 +1,77 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "compose2nix/test-app";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."docker-build-test-app" = {
+    unitConfig.Description = "Build for test-app generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    environment = {
+      BUILD_SECRET_API_TOKEN = config.sops.secrets."build/api-token".path;
+    };
+    script = ''
+      cd /some/path
+      docker build -t compose2nix/test-app --secret 'id=npm_token,src=/run/secrets/npm-token' --secret "id=api.token,src=''$BUILD_SECRET_API_TOKEN" --ssh github=/root/.ssh/id_ed25519 .
+    '';
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
@@ -0,0 +1,86 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.podman = {
+    enable = true;
+    autoPrune.enable = true;
+    dockerCompat = true;
+  };
+
+  # Enable container name DNS for all Podman networks.
+  networking.firewall.interfaces = let
+    matchAll = if !config.networking.nftables.enable then "podman+" else "podman*";
+  in {
+    "${matchAll}".allowedUDPPorts = [ 53 ];
+  };
+
+  virtualisation.oci-containers.backend = "podman";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "localhost/compose2nix/test-app";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."podman-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_default";
+    };
+    script = ''
+      podman network inspect test_default || podman network create test_default
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."podman-build-test-app" = {
+    unitConfig.Description = "Build for test-app generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    environment = {
+      BUILD_SECRET_API_TOKEN = config.sops.secrets."build/api-token".path;
+    };
+    script = ''
+      cd /some/path
+      podman build -t compose2nix/test-app --secret 'id=npm_token,src=/run/secrets/npm-token' --secret "id=api.token,src=''$BUILD_SECRET_API_TOKEN" --ssh github=/root/.ssh/id_ed25519 .
+    '';
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."podman-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
@@ -0,0 +1,128 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "compose2nix/test-app";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "docker-build-test-app.service"
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-build-test-app.service"
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-heavy" = {
+    image = "compose2nix/test-heavy";
+    labels = {
+      "compose2nix.systemd.build.IOSchedulingClass" = "idle";
+      "compose2nix.systemd.build.MemoryMax" = "8G";
+      "compose2nix.systemd.build.TimeoutSec" = "1h";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=heavy"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-heavy" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-heavy generated by compose2nix.";
+    after = [
+      "docker-build-test-heavy.service"
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-build-test-heavy.service"
+      "docker-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."docker-build-test-app" = {
+    unitConfig.Description = "Build for test-app generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      CPUQuota = "50%";
+      Nice = 10;
+      TimeoutSec = 900;
+    };
+    script = ''
+      cd /some/path/app
+      docker build -t compose2nix/test-app .
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+  systemd.services."docker-build-test-heavy" = {
+    unitConfig.Description = "Build for test-heavy generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      CPUQuota = "50%";
+      IOSchedulingClass = "idle";
+      MemoryMax = "8G";
+      Nice = 10;
+      TimeoutSec = "1h";
+    };
+    script = ''
+      cd /some/path/heavy
+      docker build -t compose2nix/test-heavy .
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
@@ -135,13 +135,19 @@
   };
   systemd.services."docker-build-test-prefetcharr" = {
     unitConfig.Description = "Build for test-prefetcharr generated by compose2nix.";
-    path = [ pkgs.docker pkgs.git ];
+    path = [ pkgs.docker pkgs.git pkgs.openssh ];
     serviceConfig = {
       Type = "oneshot";
       TimeoutSec = 300;
+      StateDirectory = "compose2nix/docker-build-test-prefetcharr";
     };
     script = ''
-      docker build -t prefetcharr https://github.com/p-hueber/prefetcharr.git
+      cd /var/lib/compose2nix/docker-build-test-prefetcharr
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'https://github.com/p-hueber/prefetcharr.git'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      docker build -t prefetcharr .
     '';
   };
 
@@ -144,13 +144,19 @@
   };
   systemd.services."podman-build-test-prefetcharr" = {
     unitConfig.Description = "Build for test-prefetcharr generated by compose2nix.";
-    path = [ pkgs.podman pkgs.git ];
+    path = [ pkgs.podman pkgs.git pkgs.openssh ];
     serviceConfig = {
       Type = "oneshot";
       TimeoutSec = 300;
+      StateDirectory = "compose2nix/podman-build-test-prefetcharr";
     };
     script = ''
-      podman build -t prefetcharr https://github.com/p-hueber/prefetcharr.git
+      cd /var/lib/compose2nix/podman-build-test-prefetcharr
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'https://github.com/p-hueber/prefetcharr.git'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      podman build -t prefetcharr .
     '';
   };
 
@@ -0,0 +1,107 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "myapp:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-host-network" = {
+    image = "compose2nix/test-host-network";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=host-network"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-host-network" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-host-network generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."docker-build-test-app" = {
+    unitConfig.Description = "Build for test-app generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd /some/path/app
+      docker build -t myapp:latest --pull -f Dockerfile.multi --target=production --cache-from=type=registry,ref=registry.example.com/myapp:cache --cache-to=type=inline --label='com.example.team=platform' --label='org.opencontainers.image.title=My App' --shm-size=268435456b --add-host=mirror.internal:10.0.0.5 --no-cache --build-context=base=docker-image://alpine:3.20 --build-context=shared=/some/shared .
+    '';
+  };
+  systemd.services."docker-build-test-host-network" = {
+    unitConfig.Description = "Build for test-host-network generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd /some/path
+      docker build -t compose2nix/test-host-network --network=host .
+    '';
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
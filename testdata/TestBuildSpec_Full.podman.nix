@@ -0,0 +1,136 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.podman = {
+    enable = true;
+    autoPrune.enable = true;
+    dockerCompat = true;
+  };
+
+  # Enable container name DNS for all Podman networks.
+  networking.firewall.interfaces = let
+    matchAll = if !config.networking.nftables.enable then "podman+" else "podman*";
+  in {
+    "${matchAll}".allowedUDPPorts = [ 53 ];
+  };
+
+  virtualisation.oci-containers.backend = "podman";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "localhost/myapp:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-host-network" = {
+    image = "localhost/compose2nix/test-host-network";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=host-network"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-host-network" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-host-network generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."podman-network-test_builder" = {
+    unitConfig.Description = "Network test_builder generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_builder";
+    };
+    script = ''
+      podman network inspect test_builder || podman network create test_builder
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+  systemd.services."podman-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_default";
+    };
+    script = ''
+      podman network inspect test_default || podman network create test_default
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."podman-build-test-app" = {
+    unitConfig.Description = "Build for test-app generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd /some/path/app
+      podman build -t myapp:latest --pull=always -f Dockerfile.multi --target=production --network=test_builder --cache-from=registry.example.com/myapp:cache --label='com.example.team=platform' --label='org.opencontainers.image.title=My App' --shm-size=268435456b --add-host=mirror.internal:10.0.0.5 --no-cache --build-context=base=docker-image://alpine:3.20 --build-context=shared=/some/shared .
+    '';
+    after = [
+      "podman-network-test_builder.service"
+    ];
+    requires = [
+      "podman-network-test_builder.service"
+    ];
+  };
+  systemd.services."podman-build-test-host-network" = {
+    unitConfig.Description = "Build for test-host-network generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd /some/path
+      podman build -t compose2nix/test-host-network --network=host .
+    '';
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."podman-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
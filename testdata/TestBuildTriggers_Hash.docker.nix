@@ -0,0 +1,166 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "compose2nix/test-app";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "docker-build-test-app.service"
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-build-test-app.service"
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-other" = {
+    image = "compose2nix/test-other";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=other"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-other" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-other generated by compose2nix.";
+    after = [
+      "docker-build-test-other.service"
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-build-test-other.service"
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-remote" = {
+    image = "compose2nix/test-remote";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=remote"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-remote" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-remote generated by compose2nix.";
+    after = [
+      "docker-build-test-remote.service"
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-build-test-remote.service"
+      "docker-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."docker-build-test-app" = {
+    unitConfig.Description = "Build for test-app generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd testdata/build-context
+      docker build -t compose2nix/test-app .
+    '';
+    restartTriggers = [
+      "3464b732ece3551b3ac256c9215d5b13f52bff81c4b16ed557841336a8aeb0d6"
+    ];
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+  systemd.services."docker-build-test-other" = {
+    unitConfig.Description = "Build for test-other generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd testdata/build-context
+      docker build -t compose2nix/test-other -f ../build-context.Dockerfile .
+    '';
+    restartTriggers = [
+      "13c970f599121dd364a4c4bc3f42eee07d1a775486cf0cb2c64dc088c948196f"
+    ];
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+  systemd.services."docker-build-test-remote" = {
+    unitConfig.Description = "Build for test-remote generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git pkgs.openssh ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+      StateDirectory = "compose2nix/docker-build-test-remote";
+    };
+    script = ''
+      cd /var/lib/compose2nix/docker-build-test-remote
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'https://github.com/aksiksi/compose2nix.git' 'main'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      docker build -t compose2nix/test-remote .
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
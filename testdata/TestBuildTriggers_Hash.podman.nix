@@ -0,0 +1,175 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.podman = {
+    enable = true;
+    autoPrune.enable = true;
+    dockerCompat = true;
+  };
+
+  # Enable container name DNS for all Podman networks.
+  networking.firewall.interfaces = let
+    matchAll = if !config.networking.nftables.enable then "podman+" else "podman*";
+  in {
+    "${matchAll}".allowedUDPPorts = [ 53 ];
+  };
+
+  virtualisation.oci-containers.backend = "podman";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "localhost/compose2nix/test-app";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "podman-build-test-app.service"
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-build-test-app.service"
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-other" = {
+    image = "localhost/compose2nix/test-other";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=other"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-other" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-other generated by compose2nix.";
+    after = [
+      "podman-build-test-other.service"
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-build-test-other.service"
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-remote" = {
+    image = "localhost/compose2nix/test-remote";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=remote"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-remote" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-remote generated by compose2nix.";
+    after = [
+      "podman-build-test-remote.service"
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-build-test-remote.service"
+      "podman-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."podman-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_default";
+    };
+    script = ''
+      podman network inspect test_default || podman network create test_default
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."podman-build-test-app" = {
+    unitConfig.Description = "Build for test-app generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd testdata/build-context
+      podman build -t compose2nix/test-app .
+    '';
+    restartTriggers = [
+      "3464b732ece3551b3ac256c9215d5b13f52bff81c4b16ed557841336a8aeb0d6"
+    ];
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+  systemd.services."podman-build-test-other" = {
+    unitConfig.Description = "Build for test-other generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd testdata/build-context
+      podman build -t compose2nix/test-other -f ../build-context.Dockerfile .
+    '';
+    restartTriggers = [
+      "13c970f599121dd364a4c4bc3f42eee07d1a775486cf0cb2c64dc088c948196f"
+    ];
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+  systemd.services."podman-build-test-remote" = {
+    unitConfig.Description = "Build for test-remote generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git pkgs.openssh ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+      StateDirectory = "compose2nix/podman-build-test-remote";
+    };
+    script = ''
+      cd /var/lib/compose2nix/podman-build-test-remote
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'https://github.com/aksiksi/compose2nix.git' 'main'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      podman build -t compose2nix/test-remote .
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."podman-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
@@ -0,0 +1,83 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-wrapper" = {
+    image = "wrapper:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=wrapper"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-wrapper" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-wrapper generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."docker-build-test-wrapper" = {
+    unitConfig.Description = "Build for test-wrapper generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    environment = {
+      DOCKERFILE = pkgs.writeText "docker-build-test-wrapper-Dockerfile" ''
+        FROM alpine:3.20
+        ARG VERSION
+        RUN apk add --no-cache curl && \
+            echo "built ''${VERSION}" > /version
+        CMD ["sh", "-c", "cat /version"]
+      '';
+    };
+    script = ''
+      cd /some/path
+      docker build -t wrapper:latest --build-arg VERSION=1.0 -f "''$DOCKERFILE" .
+    '';
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
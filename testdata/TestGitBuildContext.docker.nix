@@ -0,0 +1,304 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-https" = {
+    image = "compose2nix/test-https";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=https"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-https" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-https generated by compose2nix.";
+    after = [
+      "docker-build-test-https.service"
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-build-test-https.service"
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-local" = {
+    image = "compose2nix/test-local";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=local"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-local" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-local generated by compose2nix.";
+    after = [
+      "docker-build-test-local.service"
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-build-test-local.service"
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-pinned" = {
+    image = "compose2nix/test-pinned";
+    labels = {
+      "compose2nix.settings.buildRef" = "0123456789abcdef0123456789abcdef01234567";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=pinned"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-pinned" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-pinned generated by compose2nix.";
+    after = [
+      "docker-build-test-pinned.service"
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-build-test-pinned.service"
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-scp" = {
+    image = "compose2nix/test-scp";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=scp"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-scp" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-scp generated by compose2nix.";
+    after = [
+      "docker-build-test-scp.service"
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-build-test-scp.service"
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-shorthand" = {
+    image = "compose2nix/test-shorthand";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=shorthand"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-shorthand" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-shorthand generated by compose2nix.";
+    after = [
+      "docker-build-test-shorthand.service"
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-build-test-shorthand.service"
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-ssh" = {
+    image = "compose2nix/test-ssh";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=ssh"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-ssh" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-ssh generated by compose2nix.";
+    after = [
+      "docker-build-test-ssh.service"
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-build-test-ssh.service"
+      "docker-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."docker-build-test-https" = {
+    unitConfig.Description = "Build for test-https generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git pkgs.openssh ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+      StateDirectory = "compose2nix/docker-build-test-https";
+    };
+    script = ''
+      cd /var/lib/compose2nix/docker-build-test-https
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'https://github.com/user/repo.git' 'v1.0'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      cd 'docker'
+      docker build -t compose2nix/test-https .
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+  systemd.services."docker-build-test-local" = {
+    unitConfig.Description = "Build for test-local generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git pkgs.openssh ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+      StateDirectory = "compose2nix/docker-build-test-local";
+    };
+    script = ''
+      cd /var/lib/compose2nix/docker-build-test-local
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'file:///srv/git/repo' '89abcdef0123456789abcdef0123456789abcdef'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      cd 'app'
+      docker build -t compose2nix/test-local .
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+  systemd.services."docker-build-test-pinned" = {
+    unitConfig.Description = "Build for test-pinned generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git pkgs.openssh ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+      StateDirectory = "compose2nix/docker-build-test-pinned";
+    };
+    script = ''
+      cd /var/lib/compose2nix/docker-build-test-pinned
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'https://github.com/user/repo.git' '0123456789abcdef0123456789abcdef01234567'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      docker build -t compose2nix/test-pinned -f Dockerfile.prod .
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+  systemd.services."docker-build-test-scp" = {
+    unitConfig.Description = "Build for test-scp generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git pkgs.openssh ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+      StateDirectory = "compose2nix/docker-build-test-scp";
+    };
+    script = ''
+      cd /var/lib/compose2nix/docker-build-test-scp
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'git@github.com:user/private.git' 'main'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      docker build -t compose2nix/test-scp .
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+  systemd.services."docker-build-test-shorthand" = {
+    unitConfig.Description = "Build for test-shorthand generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git pkgs.openssh ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+      StateDirectory = "compose2nix/docker-build-test-shorthand";
+    };
+    script = ''
+      cd /var/lib/compose2nix/docker-build-test-shorthand
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'https://github.com/user/repo'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      docker build -t compose2nix/test-shorthand .
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+  systemd.services."docker-build-test-ssh" = {
+    unitConfig.Description = "Build for test-ssh generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git pkgs.openssh ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+      StateDirectory = "compose2nix/docker-build-test-ssh";
+    };
+    script = ''
+      cd /var/lib/compose2nix/docker-build-test-ssh
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'ssh://git@example.com/user/repo.git'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      docker build -t compose2nix/test-ssh .
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
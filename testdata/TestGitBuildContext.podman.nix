@@ -0,0 +1,313 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.podman = {
+    enable = true;
+    autoPrune.enable = true;
+    dockerCompat = true;
+  };
+
+  # Enable container name DNS for all Podman networks.
+  networking.firewall.interfaces = let
+    matchAll = if !config.networking.nftables.enable then "podman+" else "podman*";
+  in {
+    "${matchAll}".allowedUDPPorts = [ 53 ];
+  };
+
+  virtualisation.oci-containers.backend = "podman";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-https" = {
+    image = "localhost/compose2nix/test-https";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=https"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-https" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-https generated by compose2nix.";
+    after = [
+      "podman-build-test-https.service"
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-build-test-https.service"
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-local" = {
+    image = "localhost/compose2nix/test-local";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=local"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-local" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-local generated by compose2nix.";
+    after = [
+      "podman-build-test-local.service"
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-build-test-local.service"
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-pinned" = {
+    image = "localhost/compose2nix/test-pinned";
+    labels = {
+      "compose2nix.settings.buildRef" = "0123456789abcdef0123456789abcdef01234567";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=pinned"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-pinned" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-pinned generated by compose2nix.";
+    after = [
+      "podman-build-test-pinned.service"
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-build-test-pinned.service"
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-scp" = {
+    image = "localhost/compose2nix/test-scp";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=scp"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-scp" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-scp generated by compose2nix.";
+    after = [
+      "podman-build-test-scp.service"
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-build-test-scp.service"
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-shorthand" = {
+    image = "localhost/compose2nix/test-shorthand";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=shorthand"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-shorthand" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-shorthand generated by compose2nix.";
+    after = [
+      "podman-build-test-shorthand.service"
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-build-test-shorthand.service"
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-ssh" = {
+    image = "localhost/compose2nix/test-ssh";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=ssh"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-ssh" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-ssh generated by compose2nix.";
+    after = [
+      "podman-build-test-ssh.service"
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-build-test-ssh.service"
+      "podman-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."podman-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_default";
+    };
+    script = ''
+      podman network inspect test_default || podman network create test_default
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."podman-build-test-https" = {
+    unitConfig.Description = "Build for test-https generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git pkgs.openssh ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+      StateDirectory = "compose2nix/podman-build-test-https";
+    };
+    script = ''
+      cd /var/lib/compose2nix/podman-build-test-https
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'https://github.com/user/repo.git' 'v1.0'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      cd 'docker'
+      podman build -t compose2nix/test-https .
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+  systemd.services."podman-build-test-local" = {
+    unitConfig.Description = "Build for test-local generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git pkgs.openssh ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+      StateDirectory = "compose2nix/podman-build-test-local";
+    };
+    script = ''
+      cd /var/lib/compose2nix/podman-build-test-local
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'file:///srv/git/repo' '89abcdef0123456789abcdef0123456789abcdef'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      cd 'app'
+      podman build -t compose2nix/test-local .
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+  systemd.services."podman-build-test-pinned" = {
+    unitConfig.Description = "Build for test-pinned generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git pkgs.openssh ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+      StateDirectory = "compose2nix/podman-build-test-pinned";
+    };
+    script = ''
+      cd /var/lib/compose2nix/podman-build-test-pinned
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'https://github.com/user/repo.git' '0123456789abcdef0123456789abcdef01234567'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      podman build -t compose2nix/test-pinned -f Dockerfile.prod .
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+  systemd.services."podman-build-test-scp" = {
+    unitConfig.Description = "Build for test-scp generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git pkgs.openssh ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+      StateDirectory = "compose2nix/podman-build-test-scp";
+    };
+    script = ''
+      cd /var/lib/compose2nix/podman-build-test-scp
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'git@github.com:user/private.git' 'main'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      podman build -t compose2nix/test-scp .
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+  systemd.services."podman-build-test-shorthand" = {
+    unitConfig.Description = "Build for test-shorthand generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git pkgs.openssh ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+      StateDirectory = "compose2nix/podman-build-test-shorthand";
+    };
+    script = ''
+      cd /var/lib/compose2nix/podman-build-test-shorthand
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'https://github.com/user/repo'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      podman build -t compose2nix/test-shorthand .
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+  systemd.services."podman-build-test-ssh" = {
+    unitConfig.Description = "Build for test-ssh generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git pkgs.openssh ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+      StateDirectory = "compose2nix/podman-build-test-ssh";
+    };
+    script = ''
+      cd /var/lib/compose2nix/podman-build-test-ssh
+      [ -d .git ] || git init --quiet
+      git fetch --force --depth=1 'ssh://git@example.com/user/repo.git'
+      git checkout --force --detach FETCH_HEAD
+      git clean -ffdx
+      podman build -t compose2nix/test-ssh .
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."podman-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
@@ -0,0 +1,148 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-no-healthcheck" = {
+    image = "alpine:latest";
+    labels = {
+      "compose2nix.settings.healthOnFailure" = "stop";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=no-healthcheck"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-no-healthcheck" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-no-healthcheck generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-web" = {
+    image = "nginx:latest";
+    labels = {
+      "compose2nix.settings.healthOnFailure" = "restart";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--health-cmd=[\"curl\", \"-f\", \"http://localhost\"]"
+      "--health-interval=10s"
+      "--network-alias=web"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-web" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-web generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  systemd.services."docker-test-web-healthcheck" = {
+    unitConfig.Description = "Health check for container test-web generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig.Type = "oneshot";
+    script = ''
+      if [ "''$(docker inspect --format '{{.State.Health.Status}}' 'test-web')" = unhealthy ]; then systemctl restart 'docker-test-web.service'; fi
+    '';
+  };
+  systemd.timers."docker-test-web-healthcheck" = {
+    unitConfig.Description = "Health check timer for container test-web generated by compose2nix.";
+    timerConfig = {
+      OnActiveSec = "10s";
+      OnUnitActiveSec = "10s";
+    };
+    partOf = [ "docker-test-web.service" ];
+    wantedBy = [ "docker-test-web.service" ];
+  };
+  virtualisation.oci-containers.containers."test-worker" = {
+    image = "alpine:latest";
+    labels = {
+      "compose2nix.settings.healthOnFailure" = "kill";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--health-cmd=[\"true\"]"
+      "--network-alias=worker"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-worker" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-worker generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  systemd.services."docker-test-worker-healthcheck" = {
+    unitConfig.Description = "Health check for container test-worker generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig.Type = "oneshot";
+    script = ''
+      if [ "''$(docker inspect --format '{{.State.Health.Status}}' 'test-worker')" = unhealthy ]; then docker kill 'test-worker'; fi
+    '';
+  };
+  systemd.timers."docker-test-worker-healthcheck" = {
+    unitConfig.Description = "Health check timer for container test-worker generated by compose2nix.";
+    timerConfig = {
+      OnActiveSec = "30s";
+      OnUnitActiveSec = "30s";
+    };
+    partOf = [ "docker-test-worker.service" ];
+    wantedBy = [ "docker-test-worker.service" ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
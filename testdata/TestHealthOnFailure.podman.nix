@@ -0,0 +1,125 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.podman = {
+    enable = true;
+    autoPrune.enable = true;
+    dockerCompat = true;
+  };
+
+  # Enable container name DNS for all Podman networks.
+  networking.firewall.interfaces = let
+    matchAll = if !config.networking.nftables.enable then "podman+" else "podman*";
+  in {
+    "${matchAll}".allowedUDPPorts = [ 53 ];
+  };
+
+  virtualisation.oci-containers.backend = "podman";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-no-healthcheck" = {
+    image = "alpine:latest";
+    labels = {
+      "compose2nix.settings.healthOnFailure" = "stop";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=no-healthcheck"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-no-healthcheck" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-no-healthcheck generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-web" = {
+    image = "nginx:latest";
+    labels = {
+      "compose2nix.settings.healthOnFailure" = "restart";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--health-cmd=[\"curl\", \"-f\", \"http://localhost\"]"
+      "--health-interval=10s"
+      "--health-on-failure=restart"
+      "--network-alias=web"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-web" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-web generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-worker" = {
+    image = "alpine:latest";
+    labels = {
+      "compose2nix.settings.healthOnFailure" = "kill";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--health-cmd=[\"true\"]"
+      "--health-on-failure=kill"
+      "--network-alias=worker"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-worker" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-worker generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."podman-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_default";
+    };
+    script = ''
+      podman network inspect test_default || podman network create test_default
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."podman-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
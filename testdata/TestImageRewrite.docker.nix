@@ -0,0 +1,158 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "mirror.internal/ghcr/me/app:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-built" = {
+    image = "compose2nix/test-built";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=built"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-built" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-built generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-db" = {
+    image = "mirror.internal/dockerhub/library/postgres:16";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=db"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-db" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-db generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-unmatched" = {
+    image = "quay.io/prometheus/prometheus:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=unmatched"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-unmatched" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-unmatched generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-user" = {
+    image = "mirror.internal/dockerhub/someuser/tool@sha256:0000000000000000000000000000000000000000000000000000000000000000";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=user"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-user" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-user generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."docker-build-test-built" = {
+    unitConfig.Description = "Build for test-built generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd /some/path
+      docker build -t compose2nix/test-built --build-arg BASE_IMAGE=mirror.internal/dockerhub/library/node:20 --build-arg GIT_COMMIT=development --build-arg RUNTIME_BASE=mirror.internal/dockerhub/library/alpine:3.20 .
+    '';
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
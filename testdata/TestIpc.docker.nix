@@ -27,6 +27,7 @@
     };
     unitConfig.Description = "Container test-test-ipc-container generated by compose2nix.";
     after = [
+      "docker-external-container.service"
       "docker-network-test_default.service"
     ];
     requires = [
@@ -36,6 +36,7 @@
     };
     unitConfig.Description = "Container test-test-ipc-container generated by compose2nix.";
     after = [
+      "podman-external-container.service"
       "podman-network-test_default.service"
     ];
     requires = [
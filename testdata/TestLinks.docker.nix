@@ -0,0 +1,136 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."my-cache" = {
+    image = "redis:7";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=cache"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-my-cache" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container my-cache generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "alpine:latest";
+    dependsOn = [
+      "my-cache"
+      "test-db"
+    ];
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-db" = {
+    image = "postgres:16";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=database"
+      "--network-alias=db"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-db" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-db generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-worker" = {
+    image = "alpine:latest";
+    dependsOn = [
+      "my-cache"
+    ];
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--link=legacy-queue:legacy-queue"
+      "--link=legacy-redis:redis"
+      "--link=my-cache:cache-alias"
+      "--network-alias=worker"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-worker" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-worker generated by compose2nix.";
+    after = [
+      "docker-legacy-queue.service"
+      "docker-legacy-redis.service"
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
@@ -0,0 +1,130 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = let
+    nixImage = pkgs.callPackage ./images/app.nix { };
+  in {
+    image = "${nixImage.imageName}:${nixImage.imageTag}";
+    imageFile = nixImage;
+    labels = {
+      "compose2nix.settings.nixImage" = "pkgs.callPackage ./images/app.nix { }";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-db" = {
+    image = "postgres:16";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=db"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-db" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-db generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+      "docker-pull-test-db.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+      "docker-pull-test-db.service"
+    ];
+  };
+  systemd.services."docker-pull-test-db" = {
+    unitConfig.Description = "Pull image for test-db generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+    };
+    script = ''
+      docker image inspect 'postgres:16' >/dev/null 2>&1 && exit 0
+      docker pull 'postgres:16'
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+  };
+  virtualisation.oci-containers.containers."test-worker" = let
+    nixImage = pkgs.dockerTools.buildLayeredImage { name = "my-worker"; tag = "nix"; contents = [ pkgs.hello ]; };
+  in {
+    image = "${nixImage.imageName}:${nixImage.imageTag}";
+    imageFile = nixImage;
+    labels = {
+      "compose2nix.settings.nixImage" = "pkgs.dockerTools.buildLayeredImage { name = \"my-worker\"; tag = \"nix\"; contents = [ pkgs.hello ]; }";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=worker"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-worker" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-worker generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
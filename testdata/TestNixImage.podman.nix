@@ -0,0 +1,139 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.podman = {
+    enable = true;
+    autoPrune.enable = true;
+    dockerCompat = true;
+  };
+
+  # Enable container name DNS for all Podman networks.
+  networking.firewall.interfaces = let
+    matchAll = if !config.networking.nftables.enable then "podman+" else "podman*";
+  in {
+    "${matchAll}".allowedUDPPorts = [ 53 ];
+  };
+
+  virtualisation.oci-containers.backend = "podman";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = let
+    nixImage = pkgs.callPackage ./images/app.nix { };
+  in {
+    image = "${nixImage.imageName}:${nixImage.imageTag}";
+    imageFile = nixImage;
+    labels = {
+      "compose2nix.settings.nixImage" = "pkgs.callPackage ./images/app.nix { }";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-db" = {
+    image = "postgres:16";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=db"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-db" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-db generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+      "podman-pull-test-db.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+      "podman-pull-test-db.service"
+    ];
+  };
+  systemd.services."podman-pull-test-db" = {
+    unitConfig.Description = "Pull image for test-db generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+    };
+    script = ''
+      podman image inspect 'postgres:16' >/dev/null 2>&1 && exit 0
+      podman pull 'postgres:16'
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+  };
+  virtualisation.oci-containers.containers."test-worker" = let
+    nixImage = pkgs.dockerTools.buildLayeredImage { name = "my-worker"; tag = "nix"; contents = [ pkgs.hello ]; };
+  in {
+    image = "${nixImage.imageName}:${nixImage.imageTag}";
+    imageFile = nixImage;
+    labels = {
+      "compose2nix.settings.nixImage" = "pkgs.dockerTools.buildLayeredImage { name = \"my-worker\"; tag = \"nix\"; contents = [ pkgs.hello ]; }";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=worker"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-worker" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-worker generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."podman-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_default";
+    };
+    script = ''
+      podman network inspect test_default || podman network create test_default
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."podman-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
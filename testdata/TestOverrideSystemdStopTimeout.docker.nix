@@ -262,9 +262,6 @@
       "traefik.http.routers.traefik.service" = "api@internal";
       "traefik.http.routers.traefik.tls.certresolver" = "htpc";
     };
-    dependsOn = [
-      "sabnzbd"
-    ];
     log-driver = "journald";
     autoStart = false;
     extraOptions = [
@@ -280,6 +277,9 @@
       Description = "Container traefik generated by compose2nix.";
       AllowIsolate = lib.mkOverride 90 true;
     };
+    after = [
+      "docker-sabnzbd.service"
+    ];
   };
 
   # Networks
@@ -0,0 +1,339 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-always" = {
+    image = "nginx:latest";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=always"
+      "--network=test_default"
+      "--pull=always"
+    ];
+  };
+  systemd.services."docker-test-always" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-always generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+    partOf = [
+      "docker-compose-test-root.target"
+    ];
+    wantedBy = [
+      "docker-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-build-always" = {
+    image = "compose2nix/test-build-always";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=build-always"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-build-always" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-build-always generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+    partOf = [
+      "docker-compose-test-root.target"
+    ];
+    wantedBy = [
+      "docker-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-build-never" = {
+    image = "compose2nix/test-build-never";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=build-never"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-build-never" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-build-never generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+    partOf = [
+      "docker-compose-test-root.target"
+    ];
+    wantedBy = [
+      "docker-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-build-weekly" = {
+    image = "compose2nix/test-build-weekly";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=build-weekly"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-build-weekly" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-build-weekly generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+    partOf = [
+      "docker-compose-test-root.target"
+    ];
+    wantedBy = [
+      "docker-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-daily" = {
+    image = "nginx:latest";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=daily"
+      "--network=test_default"
+      "--pull=missing"
+    ];
+  };
+  systemd.services."docker-test-daily" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-daily generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+    partOf = [
+      "docker-compose-test-root.target"
+    ];
+    wantedBy = [
+      "docker-compose-test-root.target"
+    ];
+  };
+  systemd.services."docker-pull-test-daily" = {
+    unitConfig.Description = "Pull image for test-daily generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      docker pull 'nginx:latest'
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+  };
+  systemd.timers."docker-pull-test-daily" = {
+    unitConfig.Description = "Pull timer for test-daily generated by compose2nix.";
+    timerConfig = {
+      OnActiveSec = "24h0m0s";
+      OnUnitActiveSec = "24h0m0s";
+    };
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [
+      "timers.target"
+      "docker-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-every" = {
+    image = "nginx:latest";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=every"
+      "--network=test_default"
+      "--pull=missing"
+    ];
+  };
+  systemd.services."docker-test-every" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-every generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+    partOf = [
+      "docker-compose-test-root.target"
+    ];
+    wantedBy = [
+      "docker-compose-test-root.target"
+    ];
+  };
+  systemd.services."docker-pull-test-every" = {
+    unitConfig.Description = "Pull image for test-every generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      docker pull 'nginx:latest'
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+  };
+  systemd.timers."docker-pull-test-every" = {
+    unitConfig.Description = "Pull timer for test-every generated by compose2nix.";
+    timerConfig = {
+      OnActiveSec = "12h0m0s";
+      OnUnitActiveSec = "12h0m0s";
+    };
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [
+      "timers.target"
+      "docker-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-missing" = {
+    image = "nginx:latest";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=missing"
+      "--network=test_default"
+      "--pull=missing"
+    ];
+  };
+  systemd.services."docker-test-missing" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-missing generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+    partOf = [
+      "docker-compose-test-root.target"
+    ];
+    wantedBy = [
+      "docker-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-never" = {
+    image = "nginx:latest";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=never"
+      "--network=test_default"
+      "--pull=never"
+    ];
+  };
+  systemd.services."docker-test-never" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-never generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+    partOf = [
+      "docker-compose-test-root.target"
+    ];
+    wantedBy = [
+      "docker-compose-test-root.target"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."docker-build-test-build-always" = {
+    unitConfig.Description = "Build for test-build-always generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd /some/path
+      docker build -t compose2nix/test-build-always --pull .
+    '';
+  };
+  systemd.services."docker-build-test-build-never" = {
+    unitConfig.Description = "Build for test-build-never generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd /some/path
+      docker build -t compose2nix/test-build-never .
+    '';
+  };
+  systemd.services."docker-build-test-build-weekly" = {
+    unitConfig.Description = "Build for test-build-weekly generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd /some/path
+      docker build -t compose2nix/test-build-weekly --pull .
+    '';
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+    wantedBy = [ "multi-user.target" ];
+  };
+}
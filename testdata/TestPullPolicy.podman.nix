@@ -0,0 +1,348 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.podman = {
+    enable = true;
+    autoPrune.enable = true;
+    dockerCompat = true;
+  };
+
+  # Enable container name DNS for all Podman networks.
+  networking.firewall.interfaces = let
+    matchAll = if !config.networking.nftables.enable then "podman+" else "podman*";
+  in {
+    "${matchAll}".allowedUDPPorts = [ 53 ];
+  };
+
+  virtualisation.oci-containers.backend = "podman";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-always" = {
+    image = "nginx:latest";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=always"
+      "--network=test_default"
+      "--pull=always"
+    ];
+  };
+  systemd.services."podman-test-always" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-always generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+    partOf = [
+      "podman-compose-test-root.target"
+    ];
+    wantedBy = [
+      "podman-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-build-always" = {
+    image = "localhost/compose2nix/test-build-always";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=build-always"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-build-always" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-build-always generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+    partOf = [
+      "podman-compose-test-root.target"
+    ];
+    wantedBy = [
+      "podman-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-build-never" = {
+    image = "localhost/compose2nix/test-build-never";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=build-never"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-build-never" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-build-never generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+    partOf = [
+      "podman-compose-test-root.target"
+    ];
+    wantedBy = [
+      "podman-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-build-weekly" = {
+    image = "localhost/compose2nix/test-build-weekly";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=build-weekly"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-build-weekly" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-build-weekly generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+    partOf = [
+      "podman-compose-test-root.target"
+    ];
+    wantedBy = [
+      "podman-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-daily" = {
+    image = "nginx:latest";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=daily"
+      "--network=test_default"
+      "--pull=missing"
+    ];
+  };
+  systemd.services."podman-test-daily" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-daily generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+    partOf = [
+      "podman-compose-test-root.target"
+    ];
+    wantedBy = [
+      "podman-compose-test-root.target"
+    ];
+  };
+  systemd.services."podman-pull-test-daily" = {
+    unitConfig.Description = "Pull image for test-daily generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      podman pull 'nginx:latest'
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+  };
+  systemd.timers."podman-pull-test-daily" = {
+    unitConfig.Description = "Pull timer for test-daily generated by compose2nix.";
+    timerConfig = {
+      OnActiveSec = "24h0m0s";
+      OnUnitActiveSec = "24h0m0s";
+    };
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [
+      "timers.target"
+      "podman-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-every" = {
+    image = "nginx:latest";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=every"
+      "--network=test_default"
+      "--pull=missing"
+    ];
+  };
+  systemd.services."podman-test-every" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-every generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+    partOf = [
+      "podman-compose-test-root.target"
+    ];
+    wantedBy = [
+      "podman-compose-test-root.target"
+    ];
+  };
+  systemd.services."podman-pull-test-every" = {
+    unitConfig.Description = "Pull image for test-every generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      podman pull 'nginx:latest'
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+  };
+  systemd.timers."podman-pull-test-every" = {
+    unitConfig.Description = "Pull timer for test-every generated by compose2nix.";
+    timerConfig = {
+      OnActiveSec = "12h0m0s";
+      OnUnitActiveSec = "12h0m0s";
+    };
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [
+      "timers.target"
+      "podman-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-missing" = {
+    image = "nginx:latest";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=missing"
+      "--network=test_default"
+      "--pull=missing"
+    ];
+  };
+  systemd.services."podman-test-missing" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-missing generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+    partOf = [
+      "podman-compose-test-root.target"
+    ];
+    wantedBy = [
+      "podman-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-never" = {
+    image = "nginx:latest";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=never"
+      "--network=test_default"
+      "--pull=never"
+    ];
+  };
+  systemd.services."podman-test-never" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-never generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+    partOf = [
+      "podman-compose-test-root.target"
+    ];
+    wantedBy = [
+      "podman-compose-test-root.target"
+    ];
+  };
+
+  # Networks
+  systemd.services."podman-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_default";
+    };
+    script = ''
+      podman network inspect test_default || podman network create test_default
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."podman-build-test-build-always" = {
+    unitConfig.Description = "Build for test-build-always generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd /some/path
+      podman build -t compose2nix/test-build-always --pull=always .
+    '';
+  };
+  systemd.services."podman-build-test-build-never" = {
+    unitConfig.Description = "Build for test-build-never generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd /some/path
+      podman build -t compose2nix/test-build-never --pull=never .
+    '';
+  };
+  systemd.services."podman-build-test-build-weekly" = {
+    unitConfig.Description = "Build for test-build-weekly generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd /some/path
+      podman build -t compose2nix/test-build-weekly --pull=newer .
+    '';
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."podman-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+    wantedBy = [ "multi-user.target" ];
+  };
+}
@@ -0,0 +1,178 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "myapp:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-cache" = {
+    image = "redis:7";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=cache"
+      "--network=test_default"
+      "--pull=never"
+    ];
+  };
+  systemd.services."docker-test-cache" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-cache generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-db" = {
+    image = "postgres:16";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=db"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-db" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-db generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+      "docker-pull-test-db.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+      "docker-pull-test-db.service"
+    ];
+  };
+  systemd.services."docker-pull-test-db" = {
+    unitConfig.Description = "Pull image for test-db generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 600;
+    };
+    script = ''
+      docker image inspect 'postgres:16' >/dev/null 2>&1 && exit 0
+      for delay in 10 20; do
+        docker pull 'postgres:16' && exit 0
+        echo "pull failed, retrying in ''${delay}s" >&2
+        sleep "''$delay"
+      done
+      docker pull 'postgres:16'
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+  };
+  virtualisation.oci-containers.containers."test-web" = {
+    image = "nginx:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=web"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-web" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-web generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+      "docker-pull-test-web.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+      "docker-pull-test-web.service"
+    ];
+  };
+  systemd.services."docker-pull-test-web" = {
+    unitConfig.Description = "Pull image for test-web generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 600;
+    };
+    script = ''
+      for delay in 10 20; do
+        docker pull 'nginx:latest' && exit 0
+        echo "pull failed, retrying in ''${delay}s" >&2
+        sleep "''$delay"
+      done
+      docker pull 'nginx:latest'
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."docker-build-test-app" = {
+    unitConfig.Description = "Build for test-app generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd testdata
+      docker build -t myapp:latest .
+    '';
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
@@ -0,0 +1,187 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.podman = {
+    enable = true;
+    autoPrune.enable = true;
+    dockerCompat = true;
+  };
+
+  # Enable container name DNS for all Podman networks.
+  networking.firewall.interfaces = let
+    matchAll = if !config.networking.nftables.enable then "podman+" else "podman*";
+  in {
+    "${matchAll}".allowedUDPPorts = [ 53 ];
+  };
+
+  virtualisation.oci-containers.backend = "podman";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "localhost/myapp:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-cache" = {
+    image = "redis:7";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=cache"
+      "--network=test_default"
+      "--pull=never"
+    ];
+  };
+  systemd.services."podman-test-cache" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-cache generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-db" = {
+    image = "postgres:16";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=db"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-db" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-db generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+      "podman-pull-test-db.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+      "podman-pull-test-db.service"
+    ];
+  };
+  systemd.services."podman-pull-test-db" = {
+    unitConfig.Description = "Pull image for test-db generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 600;
+    };
+    script = ''
+      podman image inspect 'postgres:16' >/dev/null 2>&1 && exit 0
+      for delay in 10 20; do
+        podman pull 'postgres:16' && exit 0
+        echo "pull failed, retrying in ''${delay}s" >&2
+        sleep "''$delay"
+      done
+      podman pull 'postgres:16'
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+  };
+  virtualisation.oci-containers.containers."test-web" = {
+    image = "nginx:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=web"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-web" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-web generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+      "podman-pull-test-web.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+      "podman-pull-test-web.service"
+    ];
+  };
+  systemd.services."podman-pull-test-web" = {
+    unitConfig.Description = "Pull image for test-web generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 600;
+    };
+    script = ''
+      for delay in 10 20; do
+        podman pull 'nginx:latest' && exit 0
+        echo "pull failed, retrying in ''${delay}s" >&2
+        sleep "''$delay"
+      done
+      podman pull 'nginx:latest'
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+  };
+
+  # Networks
+  systemd.services."podman-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_default";
+    };
+    script = ''
+      podman network inspect test_default || podman network create test_default
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."podman-build-test-app" = {
+    unitConfig.Description = "Build for test-app generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd testdata
+      podman build -t myapp:latest .
+    '';
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."podman-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
@@ -0,0 +1,240 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "ghcr.io/me/app:latest";
+    login = {
+      registry = "ghcr.io";
+      username = "me";
+      passwordFile = "/run/secrets/ghcr-token";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+      "docker-pull-test-app.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+      "docker-pull-test-app.service"
+    ];
+  };
+  systemd.services."docker-pull-test-app" = {
+    unitConfig.Description = "Pull image for test-app generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+    };
+    script = ''
+      docker image inspect 'ghcr.io/me/app:latest' >/dev/null 2>&1 && exit 0
+      docker login 'ghcr.io' --username 'me' --password-stdin < '/run/secrets/ghcr-token'
+      docker pull 'ghcr.io/me/app:latest'
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+  };
+  virtualisation.oci-containers.containers."test-built" = {
+    image = "ghcr.io/me/built:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=built"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-built" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-built generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-db" = {
+    image = "postgres:16";
+    login = {
+      registry = "docker.io";
+      username = "hubuser";
+      passwordFile = "/run/secrets/dockerhub-\${USER}\"token\"";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=db"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-db" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-db generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+      "docker-pull-test-db.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+      "docker-pull-test-db.service"
+    ];
+  };
+  systemd.services."docker-pull-test-db" = {
+    unitConfig.Description = "Pull image for test-db generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+    };
+    script = ''
+      docker image inspect 'postgres:16' >/dev/null 2>&1 && exit 0
+      docker login 'docker.io' --username 'hubuser' --password-stdin < '/run/secrets/dockerhub-''${USER}"token"'
+      docker pull 'postgres:16'
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+  };
+  virtualisation.oci-containers.containers."test-internal" = {
+    image = "registry.example.com:5000/team/internal:1.0";
+    login = {
+      registry = "registry.example.com:5000";
+      username = "deploy";
+      passwordFile = config.sops.secrets."registry/password".path;
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=internal"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-internal" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-internal generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+      "docker-pull-test-internal.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+      "docker-pull-test-internal.service"
+    ];
+  };
+  systemd.services."docker-pull-test-internal" = {
+    unitConfig.Description = "Pull image for test-internal generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+    };
+    script = ''
+      docker image inspect 'registry.example.com:5000/team/internal:1.0' >/dev/null 2>&1 && exit 0
+      docker login 'registry.example.com:5000' --username 'deploy' --password-stdin < ${config.sops.secrets."registry/password".path}
+      docker pull 'registry.example.com:5000/team/internal:1.0'
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+  };
+  virtualisation.oci-containers.containers."test-public" = {
+    image = "quay.io/prometheus/prometheus:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=public"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-public" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-public generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+      "docker-pull-test-public.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+      "docker-pull-test-public.service"
+    ];
+  };
+  systemd.services."docker-pull-test-public" = {
+    unitConfig.Description = "Pull image for test-public generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+    };
+    script = ''
+      docker image inspect 'quay.io/prometheus/prometheus:latest' >/dev/null 2>&1 && exit 0
+      docker pull 'quay.io/prometheus/prometheus:latest'
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."docker-build-test-built" = {
+    unitConfig.Description = "Build for test-built generated by compose2nix.";
+    path = [ pkgs.docker pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd /some/path
+      docker build -t ghcr.io/me/built:latest .
+    '';
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
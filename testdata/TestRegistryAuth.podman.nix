@@ -0,0 +1,249 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.podman = {
+    enable = true;
+    autoPrune.enable = true;
+    dockerCompat = true;
+  };
+
+  # Enable container name DNS for all Podman networks.
+  networking.firewall.interfaces = let
+    matchAll = if !config.networking.nftables.enable then "podman+" else "podman*";
+  in {
+    "${matchAll}".allowedUDPPorts = [ 53 ];
+  };
+
+  virtualisation.oci-containers.backend = "podman";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "ghcr.io/me/app:latest";
+    login = {
+      registry = "ghcr.io";
+      username = "me";
+      passwordFile = "/run/secrets/ghcr-token";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+      "podman-pull-test-app.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+      "podman-pull-test-app.service"
+    ];
+  };
+  systemd.services."podman-pull-test-app" = {
+    unitConfig.Description = "Pull image for test-app generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+    };
+    script = ''
+      podman image inspect 'ghcr.io/me/app:latest' >/dev/null 2>&1 && exit 0
+      podman login 'ghcr.io' --username 'me' --password-stdin < '/run/secrets/ghcr-token'
+      podman pull 'ghcr.io/me/app:latest'
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+  };
+  virtualisation.oci-containers.containers."test-built" = {
+    image = "localhost/ghcr.io/me/built:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=built"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-built" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-built generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-db" = {
+    image = "postgres:16";
+    login = {
+      registry = "docker.io";
+      username = "hubuser";
+      passwordFile = "/run/secrets/dockerhub-\${USER}\"token\"";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=db"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-db" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-db generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+      "podman-pull-test-db.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+      "podman-pull-test-db.service"
+    ];
+  };
+  systemd.services."podman-pull-test-db" = {
+    unitConfig.Description = "Pull image for test-db generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+    };
+    script = ''
+      podman image inspect 'postgres:16' >/dev/null 2>&1 && exit 0
+      podman login 'docker.io' --username 'hubuser' --password-stdin < '/run/secrets/dockerhub-''${USER}"token"'
+      podman pull 'postgres:16'
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+  };
+  virtualisation.oci-containers.containers."test-internal" = {
+    image = "registry.example.com:5000/team/internal:1.0";
+    login = {
+      registry = "registry.example.com:5000";
+      username = "deploy";
+      passwordFile = config.sops.secrets."registry/password".path;
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=internal"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-internal" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-internal generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+      "podman-pull-test-internal.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+      "podman-pull-test-internal.service"
+    ];
+  };
+  systemd.services."podman-pull-test-internal" = {
+    unitConfig.Description = "Pull image for test-internal generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+    };
+    script = ''
+      podman image inspect 'registry.example.com:5000/team/internal:1.0' >/dev/null 2>&1 && exit 0
+      podman login 'registry.example.com:5000' --username 'deploy' --password-stdin < ${config.sops.secrets."registry/password".path}
+      podman pull 'registry.example.com:5000/team/internal:1.0'
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+  };
+  virtualisation.oci-containers.containers."test-public" = {
+    image = "quay.io/prometheus/prometheus:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=public"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-public" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-public generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+      "podman-pull-test-public.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+      "podman-pull-test-public.service"
+    ];
+  };
+  systemd.services."podman-pull-test-public" = {
+    unitConfig.Description = "Pull image for test-public generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      TimeoutSec = 300;
+    };
+    script = ''
+      podman image inspect 'quay.io/prometheus/prometheus:latest' >/dev/null 2>&1 && exit 0
+      podman pull 'quay.io/prometheus/prometheus:latest'
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+  };
+
+  # Networks
+  systemd.services."podman-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_default";
+    };
+    script = ''
+      podman network inspect test_default || podman network create test_default
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."podman-build-test-built" = {
+    unitConfig.Description = "Build for test-built generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd /some/path
+      podman build -t ghcr.io/me/built:latest .
+    '';
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."podman-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
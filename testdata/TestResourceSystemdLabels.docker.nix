@@ -0,0 +1,157 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "nginx:latest";
+    volumes = [
+      "test_nfs-data:/data:rw"
+    ];
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_backend"
+    ];
+  };
+  systemd.services."docker-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "docker-network-test_backend.service"
+      "docker-volume-test_nfs-data.service"
+    ];
+    requires = [
+      "docker-network-test_backend.service"
+      "docker-volume-test_nfs-data.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-builder" = {
+    image = "compose2nix/test-builder";
+    labels = {
+      "compose2nix.systemd.build.MemoryMax" = "4G";
+      "compose2nix.systemd.build.TimeoutSec" = "600";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=builder"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-builder" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-builder generated by compose2nix.";
+    after = [
+      "docker-build-test-builder.service"
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-build-test-builder.service"
+      "docker-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_backend" = {
+    unitConfig = {
+      Description = "Backend network";
+    };
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_backend";
+      TimeoutStartSec = 60;
+    };
+    script = ''
+      docker network inspect test_backend || docker network create test_backend --label=com.example.tier=backend
+    '';
+    after = [
+      "firewall.service"
+    ];
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Volumes
+  systemd.services."docker-volume-test_nfs-data" = {
+    unitConfig.Description = "Volume test_nfs-data generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+    };
+    unitConfig.RequiresMountsFor = [
+      "/mnt/nas"
+    ];
+    script = ''
+      docker volume inspect test_nfs-data || docker volume create test_nfs-data --opt=device=:/exports/data --opt=o=addr=nas.local,rw --opt=type=nfs --label=com.example.backup=true
+    '';
+    after = [
+      "remote-fs.target"
+    ];
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" "multi-user.target" ];
+  };
+
+  # Builds
+  systemd.services."docker-build-test-builder" = {
+    unitConfig = {
+      Description = "Build for test-builder generated by compose2nix.";
+      Wants = "network-online.target";
+    };
+    path = [ pkgs.docker pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      MemoryMax = "4G";
+      TimeoutSec = 1800;
+    };
+    script = ''
+      cd /some/path/builder
+      docker build -t compose2nix/test-builder --label='com.example.team=infra' .
+    '';
+    after = [
+      "network-online.target"
+    ];
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
@@ -0,0 +1,170 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.podman = {
+    enable = true;
+    autoPrune.enable = true;
+    dockerCompat = true;
+  };
+
+  # Enable container name DNS for all Podman networks.
+  networking.firewall.interfaces = let
+    matchAll = if !config.networking.nftables.enable then "podman+" else "podman*";
+  in {
+    "${matchAll}".allowedUDPPorts = [ 53 ];
+  };
+
+  virtualisation.oci-containers.backend = "podman";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "nginx:latest";
+    volumes = [
+      "test_nfs-data:/data:rw"
+    ];
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_backend"
+    ];
+  };
+  systemd.services."podman-test-app" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-app generated by compose2nix.";
+    after = [
+      "podman-network-test_backend.service"
+      "podman-volume-test_nfs-data.service"
+    ];
+    requires = [
+      "podman-network-test_backend.service"
+      "podman-volume-test_nfs-data.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-builder" = {
+    image = "localhost/compose2nix/test-builder";
+    labels = {
+      "compose2nix.systemd.build.MemoryMax" = "4G";
+      "compose2nix.systemd.build.TimeoutSec" = "600";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=builder"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-builder" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-builder generated by compose2nix.";
+    after = [
+      "podman-build-test-builder.service"
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-build-test-builder.service"
+      "podman-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."podman-network-test_backend" = {
+    unitConfig = {
+      Description = "Backend network";
+    };
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_backend";
+      TimeoutStartSec = 60;
+    };
+    script = ''
+      podman network inspect test_backend || podman network create test_backend --label=com.example.tier=backend
+    '';
+    after = [
+      "firewall.service"
+    ];
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+  systemd.services."podman-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_default";
+    };
+    script = ''
+      podman network inspect test_default || podman network create test_default
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Volumes
+  systemd.services."podman-volume-test_nfs-data" = {
+    unitConfig.Description = "Volume test_nfs-data generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+    };
+    unitConfig.RequiresMountsFor = [
+      "/mnt/nas"
+    ];
+    script = ''
+      podman volume inspect test_nfs-data || podman volume create test_nfs-data --opt=device=:/exports/data --opt=o=addr=nas.local,rw --opt=type=nfs --label=com.example.backup=true
+    '';
+    after = [
+      "remote-fs.target"
+    ];
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" "multi-user.target" ];
+  };
+
+  # Builds
+  systemd.services."podman-build-test-builder" = {
+    unitConfig = {
+      Description = "Build for test-builder generated by compose2nix.";
+      Wants = "network-online.target";
+    };
+    path = [ pkgs.podman pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      MemoryMax = "4G";
+      TimeoutSec = 1800;
+    };
+    script = ''
+      cd /some/path/builder
+      podman build -t compose2nix/test-builder --network=test_backend --label='com.example.team=infra' .
+    '';
+    after = [
+      "network-online.target"
+      "podman-network-test_backend.service"
+    ];
+    requires = [
+      "podman-network-test_backend.service"
+    ];
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."podman-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
@@ -0,0 +1,227 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-test-cpu" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--cpu-shares=512"
+      "--cpus=1.5"
+      "--network-alias=test-cpu"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-test-cpu" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-cpu generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-test-cpu-quota" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--cpu-period=100000"
+      "--cpu-quota=50000"
+      "--cpu-rt-period=1000000"
+      "--cpu-rt-runtime=950000"
+      "--cpuset-cpus=0-2"
+      "--network-alias=test-cpu-quota"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-test-cpu-quota" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-cpu-quota generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-test-deploy" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--cpus=0.5"
+      "--memory=1073741824b"
+      "--network-alias=test-deploy"
+      "--network=test_default"
+      "--pids-limit=200"
+    ];
+  };
+  systemd.services."docker-test-test-deploy" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-deploy generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-test-memory" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--memory-reservation=268435456b"
+      "--memory-swap=1073741824b"
+      "--memory-swappiness=60"
+      "--memory=536870912b"
+      "--network-alias=test-memory"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-test-memory" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-memory generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-test-memory-no-swap" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--memory-swappiness=0"
+      "--network-alias=test-memory-no-swap"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-test-memory-no-swap" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-memory-no-swap generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-test-memory-swap-only" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=test-memory-swap-only"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-test-memory-swap-only" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-memory-swap-only generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-test-memory-unlimited-swap" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--memory-swap=-1"
+      "--memory=536870912b"
+      "--network-alias=test-memory-unlimited-swap"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-test-memory-unlimited-swap" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-memory-unlimited-swap generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-test-pids" = {
+    image = "alpine:latest";
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=test-pids"
+      "--network=test_default"
+      "--oom-kill-disable"
+      "--oom-score-adj=-500"
+      "--pids-limit=100"
+    ];
+  };
+  systemd.services."docker-test-test-pids" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-test-pids generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
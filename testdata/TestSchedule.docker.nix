@@ -0,0 +1,184 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-backup" = {
+    image = "restic/restic:latest";
+    labels = {
+      "compose2nix.settings.schedule" = "daily";
+      "compose2nix.settings.schedule.randomizedDelaySec" = "1h";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=backup"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-backup" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+      Type = lib.mkOverride 90 "oneshot";
+    };
+    unitConfig.Description = "Container test-backup generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  systemd.timers."docker-test-backup" = {
+    unitConfig.Description = "Timer for container test-backup generated by compose2nix.";
+    timerConfig = {
+      OnCalendar = "daily";
+      Persistent = true;
+      RandomizedDelaySec = "1h";
+    };
+    partOf = [
+      "docker-compose-test-root.target"
+    ];
+    wantedBy = [
+      "timers.target"
+      "docker-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-cleanup" = {
+    image = "alpine:latest";
+    cmd = [ "sh" "-c" "rm -rf /data/tmp/*" ];
+    labels = {
+      "compose2nix.settings.schedule" = "*-*-* 03:00:00";
+      "compose2nix.settings.schedule.persistent" = "false";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=cleanup"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-cleanup" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+      Type = lib.mkOverride 90 "oneshot";
+    };
+    unitConfig.Description = "Container test-cleanup generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  systemd.timers."docker-test-cleanup" = {
+    unitConfig.Description = "Timer for container test-cleanup generated by compose2nix.";
+    timerConfig = {
+      OnCalendar = "*-*-* 03:00:00";
+      Persistent = false;
+    };
+    partOf = [
+      "docker-compose-test-root.target"
+    ];
+    wantedBy = [
+      "timers.target"
+      "docker-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-manual" = {
+    image = "alpine:latest";
+    labels = {
+      "compose2nix.settings.autoStart" = "false";
+      "compose2nix.settings.schedule" = "weekly";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=manual"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-manual" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+      Type = lib.mkOverride 90 "oneshot";
+    };
+    unitConfig.Description = "Container test-manual generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+  systemd.timers."docker-test-manual" = {
+    unitConfig.Description = "Timer for container test-manual generated by compose2nix.";
+    timerConfig = {
+      OnCalendar = "weekly";
+      Persistent = true;
+    };
+  };
+  virtualisation.oci-containers.containers."test-web" = {
+    image = "nginx:latest";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=web"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-web" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "always";
+      RestartMaxDelaySec = lib.mkOverride 90 "1m";
+      RestartSec = lib.mkOverride 90 "100ms";
+      RestartSteps = lib.mkOverride 90 9;
+    };
+    unitConfig.Description = "Container test-web generated by compose2nix.";
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+    partOf = [
+      "docker-compose-test-root.target"
+    ];
+    wantedBy = [
+      "docker-compose-test-root.target"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+    wantedBy = [ "multi-user.target" ];
+  };
+}
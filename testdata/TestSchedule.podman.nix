@@ -0,0 +1,193 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.podman = {
+    enable = true;
+    autoPrune.enable = true;
+    dockerCompat = true;
+  };
+
+  # Enable container name DNS for all Podman networks.
+  networking.firewall.interfaces = let
+    matchAll = if !config.networking.nftables.enable then "podman+" else "podman*";
+  in {
+    "${matchAll}".allowedUDPPorts = [ 53 ];
+  };
+
+  virtualisation.oci-containers.backend = "podman";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-backup" = {
+    image = "restic/restic:latest";
+    labels = {
+      "compose2nix.settings.schedule" = "daily";
+      "compose2nix.settings.schedule.randomizedDelaySec" = "1h";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--detach=false"
+      "--network-alias=backup"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-backup" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+      Type = lib.mkOverride 90 "oneshot";
+    };
+    unitConfig.Description = "Container test-backup generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+  systemd.timers."podman-test-backup" = {
+    unitConfig.Description = "Timer for container test-backup generated by compose2nix.";
+    timerConfig = {
+      OnCalendar = "daily";
+      Persistent = true;
+      RandomizedDelaySec = "1h";
+    };
+    partOf = [
+      "podman-compose-test-root.target"
+    ];
+    wantedBy = [
+      "timers.target"
+      "podman-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-cleanup" = {
+    image = "alpine:latest";
+    cmd = [ "sh" "-c" "rm -rf /data/tmp/*" ];
+    labels = {
+      "compose2nix.settings.schedule" = "*-*-* 03:00:00";
+      "compose2nix.settings.schedule.persistent" = "false";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--detach=false"
+      "--network-alias=cleanup"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-cleanup" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+      Type = lib.mkOverride 90 "oneshot";
+    };
+    unitConfig.Description = "Container test-cleanup generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+  systemd.timers."podman-test-cleanup" = {
+    unitConfig.Description = "Timer for container test-cleanup generated by compose2nix.";
+    timerConfig = {
+      OnCalendar = "*-*-* 03:00:00";
+      Persistent = false;
+    };
+    partOf = [
+      "podman-compose-test-root.target"
+    ];
+    wantedBy = [
+      "timers.target"
+      "podman-compose-test-root.target"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-manual" = {
+    image = "alpine:latest";
+    labels = {
+      "compose2nix.settings.autoStart" = "false";
+      "compose2nix.settings.schedule" = "weekly";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--detach=false"
+      "--network-alias=manual"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-manual" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+      Type = lib.mkOverride 90 "oneshot";
+    };
+    unitConfig.Description = "Container test-manual generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+  systemd.timers."podman-test-manual" = {
+    unitConfig.Description = "Timer for container test-manual generated by compose2nix.";
+    timerConfig = {
+      OnCalendar = "weekly";
+      Persistent = true;
+    };
+  };
+  virtualisation.oci-containers.containers."test-web" = {
+    image = "nginx:latest";
+    log-driver = "journald";
+    extraOptions = [
+      "--network-alias=web"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-web" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "always";
+    };
+    unitConfig.Description = "Container test-web generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+    partOf = [
+      "podman-compose-test-root.target"
+    ];
+    wantedBy = [
+      "podman-compose-test-root.target"
+    ];
+  };
+
+  # Networks
+  systemd.services."podman-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_default";
+    };
+    script = ''
+      podman network inspect test_default || podman network create test_default
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."podman-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+    wantedBy = [ "multi-user.target" ];
+  };
+}
@@ -0,0 +1,69 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "nginx:latest";
+    labels = {
+      "compose2nix.systemd.service.Environment" = "\"GREETING=hello world\" \"PATTERN=a\\b\"";
+      "compose2nix.systemd.service.ExecStartPre" = "/bin/sh -c \"echo \${HOME}\"";
+      "compose2nix.systemd.unit.Description" = "The \"app\" container";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-app" = {
+    serviceConfig = {
+      Environment = lib.mkOverride 90 "\"GREETING=hello world\" \"PATTERN=a\\b\"";
+      ExecStartPre = lib.mkOverride 90 "/bin/sh -c \"echo \${HOME}\"";
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig = {
+      Description = lib.mkOverride 90 "The \"app\" container";
+    };
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
@@ -273,9 +273,6 @@
       "traefik.http.routers.traefik.service" = "api@internal";
       "traefik.http.routers.traefik.tls.certresolver" = "htpc";
     };
-    dependsOn = [
-      "sabnzbd"
-    ];
     log-driver = "journald";
     autoStart = false;
     extraOptions = [
@@ -290,6 +287,9 @@
       Description = "Container traefik generated by compose2nix.";
       AllowIsolate = lib.mkOverride 90 true;
     };
+    after = [
+      "podman-sabnzbd.service"
+    ];
     unitConfig.RequiresMountsFor = [
       "/var/run/podman/podman.sock"
       "/var/volumes/traefik"
@@ -0,0 +1,136 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.podman = {
+    enable = true;
+    autoPrune.enable = true;
+    dockerCompat = true;
+  };
+
+  # Enable container name DNS for all Podman networks.
+  networking.firewall.interfaces = let
+    matchAll = if !config.networking.nftables.enable then "podman+" else "podman*";
+  in {
+    "${matchAll}".allowedUDPPorts = [ 53 ];
+  };
+
+  virtualisation.oci-containers.backend = "podman";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "nginx:latest";
+    labels = {
+      "compose2nix.systemd.service.Environment.0" = "FOO=bar";
+      "compose2nix.systemd.service.Environment.1" = "BAZ=qux";
+      "compose2nix.systemd.service.ExecStartPre.0" = "/run/current-system/sw/bin/mkdir -p /srv/app";
+      "compose2nix.systemd.service.ExecStartPre.1" = "/run/current-system/sw/bin/chown 1000 /srv/app";
+      "compose2nix.systemd.service.ReadWritePaths.0" = "/srv/app";
+      "compose2nix.systemd.service.RuntimeMaxSec" = "360";
+      "compose2nix.systemd.unit.After.0" = "network-online.target";
+      "compose2nix.systemd.unit.After.1" = "remote-fs.target";
+      "compose2nix.systemd.unit.BindsTo.0" = "srv-app.mount";
+      "compose2nix.systemd.unit.Conflicts.10" = "maintenance.target";
+      "compose2nix.systemd.unit.Conflicts.2" = "shutdown.target";
+      "compose2nix.systemd.unit.Wants.0" = "network-online.target";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-app" = {
+    serviceConfig = {
+      Environment = [ "FOO=bar" "BAZ=qux" ];
+      ExecStartPre = [ "/run/current-system/sw/bin/mkdir -p /srv/app" "/run/current-system/sw/bin/chown 1000 /srv/app" ];
+      ReadWritePaths = [ "/srv/app" ];
+      Restart = lib.mkOverride 90 "no";
+      RuntimeMaxSec = lib.mkOverride 90 360;
+    };
+    unitConfig = {
+      Description = "Container test-app generated by compose2nix.";
+      BindsTo = [ "srv-app.mount" ];
+      Conflicts = [ "shutdown.target" "maintenance.target" ];
+      Wants = [ "network-online.target" ];
+    };
+    after = [
+      "network-online.target"
+      "podman-network-test_default.service"
+      "remote-fs.target"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+  virtualisation.oci-containers.containers."test-builder" = {
+    image = "localhost/compose2nix/test-builder";
+    labels = {
+      "compose2nix.systemd.build.Environment.0" = "DOCKER_BUILDKIT=1";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=builder"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-builder" = {
+    serviceConfig = {
+      Restart = lib.mkOverride 90 "no";
+    };
+    unitConfig.Description = "Container test-builder generated by compose2nix.";
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."podman-network-test_default" = {
+    unitConfig = {
+      Description = "Network test_default generated by compose2nix.";
+      BindsTo = [ "sys-subsystem-net-devices-eth0.device" ];
+    };
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_default";
+    };
+    script = ''
+      podman network inspect test_default || podman network create test_default
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Builds
+  systemd.services."podman-build-test-builder" = {
+    unitConfig.Description = "Build for test-builder generated by compose2nix.";
+    path = [ pkgs.podman pkgs.git ];
+    serviceConfig = {
+      Type = "oneshot";
+      Environment = [ "DOCKER_BUILDKIT=1" ];
+      TimeoutSec = 300;
+    };
+    script = ''
+      cd /some/path/builder
+      podman build -t compose2nix/test-builder .
+    '';
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."podman-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
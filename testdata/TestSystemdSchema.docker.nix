@@ -0,0 +1,75 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, ... }:
+
+{
+  # Runtime
+  virtualisation.docker = {
+    enable = true;
+    autoPrune.enable = true;
+  };
+  virtualisation.oci-containers.backend = "docker";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "nginx:latest";
+    labels = {
+      "compose2nix.systemd.service.MemoryMax" = "1G";
+      "compose2nix.systemd.service.PrivateTmp" = "yes";
+      "compose2nix.systemd.service.RestartSecs" = "5";
+      "compose2nix.systemd.service.TimeoutStopSec" = "2min 30s";
+      "compose2nix.systemd.unit.Description" = "1";
+      "compose2nix.systemd.unit.StartLimitIntervalSec" = "infinity";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."docker-test-app" = {
+    serviceConfig = {
+      MemoryMax = lib.mkOverride 90 "1G";
+      PrivateTmp = lib.mkOverride 90 true;
+      Restart = lib.mkOverride 90 "no";
+      RestartSecs = lib.mkOverride 90 5;
+      TimeoutStopSec = lib.mkOverride 90 "2min 30s";
+    };
+    unitConfig = {
+      Description = lib.mkOverride 90 "1";
+      StartLimitIntervalSec = lib.mkOverride 90 "infinity";
+    };
+    after = [
+      "docker-network-test_default.service"
+    ];
+    requires = [
+      "docker-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."docker-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.docker ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "docker network rm -f test_default";
+    };
+    script = ''
+      docker network inspect test_default || docker network create test_default
+    '';
+    partOf = [ "docker-compose-test-root.target" ];
+    wantedBy = [ "docker-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."docker-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
@@ -0,0 +1,84 @@
+# Auto-generated by compose2nix.
+
+{ pkgs, lib, config, ... }:
+
+{
+  # Runtime
+  virtualisation.podman = {
+    enable = true;
+    autoPrune.enable = true;
+    dockerCompat = true;
+  };
+
+  # Enable container name DNS for all Podman networks.
+  networking.firewall.interfaces = let
+    matchAll = if !config.networking.nftables.enable then "podman+" else "podman*";
+  in {
+    "${matchAll}".allowedUDPPorts = [ 53 ];
+  };
+
+  virtualisation.oci-containers.backend = "podman";
+
+  # Containers
+  virtualisation.oci-containers.containers."test-app" = {
+    image = "nginx:latest";
+    labels = {
+      "compose2nix.systemd.service.MemoryMax" = "1G";
+      "compose2nix.systemd.service.PrivateTmp" = "yes";
+      "compose2nix.systemd.service.RestartSecs" = "5";
+      "compose2nix.systemd.service.TimeoutStopSec" = "2min 30s";
+      "compose2nix.systemd.unit.Description" = "1";
+      "compose2nix.systemd.unit.StartLimitIntervalSec" = "infinity";
+    };
+    log-driver = "journald";
+    autoStart = false;
+    extraOptions = [
+      "--network-alias=app"
+      "--network=test_default"
+    ];
+  };
+  systemd.services."podman-test-app" = {
+    serviceConfig = {
+      MemoryMax = lib.mkOverride 90 "1G";
+      PrivateTmp = lib.mkOverride 90 true;
+      Restart = lib.mkOverride 90 "no";
+      RestartSecs = lib.mkOverride 90 5;
+      TimeoutStopSec = lib.mkOverride 90 "2min 30s";
+    };
+    unitConfig = {
+      Description = lib.mkOverride 90 "1";
+      StartLimitIntervalSec = lib.mkOverride 90 "infinity";
+    };
+    after = [
+      "podman-network-test_default.service"
+    ];
+    requires = [
+      "podman-network-test_default.service"
+    ];
+  };
+
+  # Networks
+  systemd.services."podman-network-test_default" = {
+    unitConfig.Description = "Network test_default generated by compose2nix.";
+    path = [ pkgs.podman ];
+    serviceConfig = {
+      Type = "oneshot";
+      RemainAfterExit = true;
+      ExecStop = "podman network rm -f test_default";
+    };
+    script = ''
+      podman network inspect test_default || podman network create test_default
+    '';
+    partOf = [ "podman-compose-test-root.target" ];
+    wantedBy = [ "podman-compose-test-root.target" ];
+  };
+
+  # Root service
+  # When started, this will automatically create all resources and start
+  # the containers. When stopped, this will teardown all resources.
+  systemd.targets."podman-compose-test-root" = {
+    unitConfig = {
+      Description = "Root target generated by compose2nix.";
+    };
+  };
+}
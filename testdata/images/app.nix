@@ -0,0 +1,7 @@
+{ dockerTools, hello }:
+
+dockerTools.buildLayeredImage {
+  name = "app";
+  tag = "latest";
+  contents = [ hello ];
+}
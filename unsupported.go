@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+// keySet describes the Compose keys consumed by the generator.
+//
+// A nil value means that the key and everything nested under it is consumed.
+// A non-nil value lists the consumed sub-keys of a partially supported key.
+type keySet map[string]keySet
+
+var supportedServiceKeys = keySet{
+	"annotations":  nil,
+	"blkio_config": nil,
+	"build": keySet{
+		"additional_contexts": nil,
+		"args":                nil,
+		"cache_from":          nil,
+		"cache_to":            nil,
+		"context":             nil,
+		"dockerfile":          nil,
+		"dockerfile_inline":   nil,
+		"extra_hosts":         nil,
+		"labels":              nil,
+		"network":             nil,
+		"no_cache":            nil,
+		"platforms":           nil,
+		"pull":                nil,
+		"secrets":             nil,
+		"shm_size":            nil,
+		"ssh":                 nil,
+		"tags":                nil,
+		"target":              nil,
+	},
+	"cap_add":             nil,
+	"cap_drop":            nil,
+	"cgroup":              nil,
+	"cgroup_parent":       nil,
+	"command":             nil,
+	"container_name":      nil,
+	"cpu_period":          nil,
+	"cpu_quota":           nil,
+	"cpu_rt_period":       nil,
+	"cpu_rt_runtime":      nil,
+	"cpu_shares":          nil,
+	"cpus":                nil,
+	"cpuset":              nil,
+	"depends_on":          nil,
+	"device_cgroup_rules": nil,
+	"devices":             nil,
+	"deploy": keySet{
+		"resources": keySet{
+			"limits":       keySet{"cpus": nil, "memory": nil, "pids": nil},
+			"reservations": keySet{"memory": nil, "devices": nil},
+		},
+		"restart_policy": nil,
+	},
+	"dns":              nil,
+	"domainname":       nil,
+	"entrypoint":       nil,
+	"env_file":         nil,
+	"environment":      nil,
+	"external_links":   nil,
+	"extends":          nil,
+	"extra_hosts":      nil,
+	"group_add":        nil,
+	"healthcheck":      nil,
+	"hostname":         nil,
+	"image":            nil,
+	"init":             nil,
+	"ipc":              nil,
+	"label_file":       nil,
+	"labels":           nil,
+	"links":            nil,
+	"log_driver":       nil,
+	"log_opt":          nil,
+	"logging":          nil,
+	"mac_address":      nil,
+	"mem_limit":        nil,
+	"mem_reservation":  nil,
+	"mem_swappiness":   nil,
+	"memswap_limit":    nil,
+	"name":             nil, // Always set from the service key.
+	"network_mode":     nil,
+	"networks":         nil,
+	"oom_kill_disable": nil,
+	"oom_score_adj":    nil,
+	"pid":              nil,
+	"pids_limit":       nil,
+	"platform":         nil,
+	"ports":            nil,
+	"post_start":       nil,
+	"pre_stop":         nil,
+	"privileged":       nil,
+	"profiles":         nil,
+	"pull_policy":      nil,
+	"read_only":        nil,
+	"restart":          nil,
+	"runtime":          nil,
+	"security_opt":     nil,
+	"shm_size":         nil,
+	"stdin_open":       nil,
+	"storage_opt":      nil,
+	"sysctls":          nil,
+	"tmpfs":            nil,
+	"tty":              nil,
+	"ulimits":          nil,
+	"user":             nil,
+	"userns_mode":      nil,
+	"uts":              nil,
+	"volumes_from":     nil,
+	"volumes":          nil,
+	"working_dir":      nil,
+}
+
+var supportedNetworkKeys = keySet{
+	"name":        nil,
+	"driver":      nil,
+	"driver_opts": nil,
+	"ipam":        keySet{"driver": nil, "config": nil},
+	"external":    nil,
+	"internal":    nil,
+	"labels":      nil,
+	"enable_ipv6": nil,
+}
+
+var supportedVolumeKeys = keySet{
+	"name":        nil,
+	"driver":      nil,
+	"driver_opts": nil,
+	"external":    nil,
+	"labels":      nil,
+}
+
+// unsupportedKeys walks the given Compose struct and returns the (dotted) YAML
+// keys of all non-zero fields that are not in the supported key set.
+func unsupportedKeys(v reflect.Value, supported keySet, prefix string) []string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var keys []string
+	t := v.Type()
+	for i := range t.NumField() {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		// Skip internal fields and x-* extensions.
+		if key == "" || key == "-" || strings.HasPrefix(key, "#") {
+			continue
+		}
+		field := v.Field(i)
+		if field.IsZero() {
+			continue
+		}
+		subKeys, ok := supported[key]
+		switch {
+		case !ok:
+			keys = append(keys, prefix+key)
+		case subKeys != nil:
+			keys = append(keys, unsupportedKeys(field, subKeys, prefix+key+".")...)
+		}
+	}
+	return keys
+}
+
+// checkUnsupportedServiceKeys reports any Compose service keys that are set
+// but not consumed by the generator.
+func (g *Generator) checkUnsupportedServiceKeys(service types.ServiceConfig) error {
+	for _, key := range unsupportedKeys(reflect.ValueOf(service), supportedServiceKeys, "") {
+		if err := g.checkOrWarn("service %q: %q is not supported and will be ignored", service.Name, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkUnsupportedProjectKeys reports any top-level Compose keys that are set
+// but not consumed by the generator.
+func (g *Generator) checkUnsupportedProjectKeys(composeProject *types.Project) error {
+	var warnings []string
+	for _, name := range slices.Sorted(maps.Keys(composeProject.Networks)) {
+		for _, key := range unsupportedKeys(reflect.ValueOf(composeProject.Networks[name]), supportedNetworkKeys, "") {
+			warnings = append(warnings, fmt.Sprintf("network %q: %q is not supported and will be ignored", name, key))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(composeProject.Volumes)) {
+		for _, key := range unsupportedKeys(reflect.ValueOf(composeProject.Volumes[name]), supportedVolumeKeys, "") {
+			warnings = append(warnings, fmt.Sprintf("volume %q: %q is not supported and will be ignored", name, key))
+		}
+	}
+	// Secrets are only supported for builds.
+	buildSecrets := map[string]bool{}
+	for _, service := range composeProject.Services {
+		if service.Build != nil {
+			for _, secret := range service.Build.Secrets {
+				buildSecrets[secret.Source] = true
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(composeProject.Secrets)) {
+		if buildSecrets[name] {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("secret %q: top-level secrets are not supported and will be ignored", name))
+	}
+	for _, name := range slices.Sorted(maps.Keys(composeProject.Configs)) {
+		warnings = append(warnings, fmt.Sprintf("config %q: top-level configs are not supported and will be ignored", name))
+	}
+	for _, w := range warnings {
+		if err := g.checkOrWarn("%s", w); err != nil {
+			return err
+		}
+	}
+	return nil
+}